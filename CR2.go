@@ -2,21 +2,30 @@ package main
 
 import (
 	"bytes"
-	"encoding/binary"
 	"fmt"
-	"github.com/lpautet/cr2cv/bufreader"
+	"github.com/lpautet/cr2cv/colorspace"
 	"github.com/lpautet/cr2cv/cr2"
+	"github.com/lpautet/cr2cv/demosaic"
 	"image"
 	"image/jpeg"
 	"image/png"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 )
 
-var cr = cr2.CR2File{}
+var cr *cr2.CR2File
 
 func main() {
+	if len(os.Args) >= 4 && os.Args[1] == "scrub" {
+		if err := scrub(os.Args[2], os.Args[3], os.Args[4:]); err != nil {
+			fmt.Printf("Unable to scrub %s: %v\n", os.Args[2], err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	//fp, err := os.Open("/Users/lpautet/Pictures/2016/2016-04-08/IMG_0739.CR2")
 	//fp, err := os.Open("/Users/lpautet/Desktop/IMG_2188.CR2")
 	fp, err := os.Open("/Users/lpautet/tmp/IMG_8502.CR2")
@@ -27,37 +36,186 @@ func main() {
 
 	defer fp.Close()
 
-	fr := bufreader.BufferReader{Reader: fp, ByteOrder: binary.LittleEndian}
-
-	cr.Init()
-
-	cr.ReadFrom(&fr)
+	cr, err = cr2.Open(fp)
+	if err != nil {
+		fmt.Printf("Unable to read %s: %v\n", fp.Name(), err)
+		os.Exit(1)
+	}
 
-	fmt.Printf("offset=%d\n", fr.Offset)
+	cr.DumpTags()
 
 	///Users/lpautet/Pictures/2016/2016-04-08/IMG_0739.CR2
 	http.HandleFunc("/0/", handler0)
 	http.HandleFunc("/1/", handler1)
 	http.HandleFunc("/2/", handler2)
 	http.HandleFunc("/3/", handler3)
+	http.HandleFunc("/4/", handler4)
+	http.HandleFunc("/5/", handler5)
+	http.HandleFunc("/6/", handler6)
 
 	http.ListenAndServe(":8888", nil)
 }
 
 func handler1(w http.ResponseWriter, _ *http.Request) {
-	writeJpegImage(w, cr.Image1)
+	img, err := cr.Thumbnail()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJpegImage(w, img)
 }
 
 func handler0(w http.ResponseWriter, _ *http.Request) {
-	writeJpegImage(w, cr.Image0)
+	img, err := cr.Preview()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJpegImage(w, img)
 }
 
 func handler2(w http.ResponseWriter, _ *http.Request) {
-	writeImage(w, cr.Image2)
+	img, err := cr.RGBPreview()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeImage(w, img)
 }
 
 func handler3(w http.ResponseWriter, _ *http.Request) {
-	writeImage(w, cr.Image3)
+	var img image.Image
+	img, err := cr.Raw()
+	if err == cr2.ErrSRaw {
+		img, err = cr.SRaw()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeImage(w, img)
+}
+
+// handler4 serves the developed raw image, /4/?demosaic=vng&wb=daylight
+// selecting the interpolation and white balance, /4/?mul=2,1,1.5 giving custom
+// multipliers, /4/?space=adobergb&linear=1 the output colour space and
+// encoding, /4/?highlights=blend the highlight handling
+func handler4(w http.ResponseWriter, r *http.Request) {
+	var opts cr2.DevelopOptions
+	if name := r.URL.Query().Get("wb"); name != "" {
+		mode, err := cr2.ParseWhiteBalanceMode(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.WhiteBalance = mode
+	}
+	if mul := r.URL.Query().Get("mul"); mul != "" {
+		if _, err := fmt.Sscanf(mul, "%f,%f,%f", &opts.Multipliers[0], &opts.Multipliers[1], &opts.Multipliers[2]); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.WhiteBalance = cr2.WhiteBalanceCustom
+	}
+	if name := r.URL.Query().Get("space"); name != "" {
+		space, err := colorspace.ParseSpace(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.ColorSpace = space
+	}
+	if name := r.URL.Query().Get("highlights"); name != "" {
+		mode, err := cr2.ParseHighlightMode(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.Highlights = mode
+	}
+	opts.Linear = r.URL.Query().Get("linear") != ""
+	if name := r.URL.Query().Get("demosaic"); name != "" {
+		algorithm, err := demosaic.ParseAlgorithm(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.Demosaic = algorithm
+	}
+	img, err := cr.Develop(opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeImage(w, img)
+}
+
+// handler5 serves the mask of the saturated raw samples
+func handler5(w http.ResponseWriter, _ *http.Request) {
+	img, err := cr.Raw()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeImage(w, img.ClippingMask())
+}
+
+// handler6 serves the preview with the AF points in focus outlined
+func handler6(w http.ResponseWriter, _ *http.Request) {
+	img, err := cr.AFPreview()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJpegImage(w, img)
+}
+
+// scrub writes a copy of a CR2 file without identifying metadata. The tags
+// removed default to cr2.DefaultScrubOptions, or are listed as 0xa431 for an
+// EXIF tag, canon:0x000c for a maker note tag and gps for the GPS data.
+func scrub(input string, output string, tags []string) error {
+	opts := cr2.DefaultScrubOptions
+	if len(tags) > 0 {
+		opts = cr2.ScrubOptions{}
+		for _, tag := range tags {
+			if tag == "gps" {
+				opts.GPS = true
+				continue
+			}
+			tagID, err := strconv.ParseUint(strings.TrimPrefix(tag, "canon:"), 0, 16)
+			if err != nil {
+				return fmt.Errorf("invalid tag %s: %v", tag, err)
+			}
+			if strings.HasPrefix(tag, "canon:") {
+				opts.CanonTags = append(opts.CanonTags, uint16(tagID))
+			} else {
+				opts.ExifTags = append(opts.ExifTags, uint16(tagID))
+			}
+		}
+	}
+
+	in, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	cf, err := cr2.Open(in)
+	if err != nil {
+		return err
+	}
+	for _, name := range cf.Scrub(opts) {
+		fmt.Printf("Scrubbed %s\n", name)
+	}
+
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := cr2.Write(out, cf); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
 }
 
 func writeJpegImage(w http.ResponseWriter, img image.Image) {
@@ -74,7 +232,7 @@ func writeJpegImage(w http.ResponseWriter, img image.Image) {
 	}
 }
 
-func writeImage(w http.ResponseWriter, img *image.RGBA64) {
+func writeImage(w http.ResponseWriter, img image.Image) {
 
 	buffer := new(bytes.Buffer)
 	if err := png.Encode(buffer, img); err != nil {
@@ -1,79 +1,132 @@
 package bufreader
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 )
 
+var ErrIncompleteRead = errors.New("bufreader: incomplete read")
+
+// BufferReader reads fixed size values from an io.ReaderAt, keeping track of
+// the current offset. The offset can be moved anywhere, so data can be read in
+// any order.
 type BufferReader struct {
-	Reader    io.Reader
+	Reader    io.ReaderAt
 	ByteOrder binary.ByteOrder
 	Offset    int64
 }
 
-func (fr *BufferReader) ReadInto(limit int64, data interface{}) {
-	limitedReader := io.LimitedReader{R: fr.Reader, N: limit}
-	err := binary.Read(&limitedReader, fr.ByteOrder, data)
-	if err != nil {
-		panic(err)
-	}
-	if limitedReader.N != 0 {
-		panic("Incomplete Read")
+func NewBufferReader(reader io.ReaderAt, byteOrder binary.ByteOrder) *BufferReader {
+	return &BufferReader{Reader: reader, ByteOrder: byteOrder}
+}
+
+func (fr *BufferReader) Read(p []byte) (int, error) {
+	n, err := fr.Reader.ReadAt(p, fr.Offset)
+	fr.Offset += int64(n)
+	if err == io.EOF && n == len(p) {
+		err = nil
 	}
-	fr.Offset += limit
+	return n, err
 }
 
-func (fr *BufferReader) ReadByte() byte {
-	var ret byte
-	err := binary.Read(fr.Reader, fr.ByteOrder, &ret)
-	if err != nil {
-		panic(err)
+func (fr *BufferReader) ReadAt(p []byte, offset int64) (int, error) {
+	return fr.Reader.ReadAt(p, offset)
+}
+
+func (fr *BufferReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += fr.Offset
+	default:
+		return fr.Offset, fmt.Errorf("bufreader: unsupported whence %d", whence)
+	}
+	if offset < 0 {
+		return fr.Offset, fmt.Errorf("bufreader: negative offset %d", offset)
 	}
-	fr.Offset += 1
-	return ret
+	fr.Offset = offset
+	return offset, nil
 }
 
-func (fr *BufferReader) ReadUint16() uint16 {
-	var ret uint16
-	err := binary.Read(fr.Reader, fr.ByteOrder, &ret)
-	if err != nil {
-		panic(err)
+// At returns a new reader sharing the same source, positioned at offset.
+func (fr *BufferReader) At(offset int64) *BufferReader {
+	return &BufferReader{Reader: fr.Reader, ByteOrder: fr.ByteOrder, Offset: offset}
+}
+
+// CheckAvailable returns ErrIncompleteRead when size bytes cannot be read at
+// offset, so that sizes read from a file can be rejected before allocating
+// them.
+func (fr *BufferReader) CheckAvailable(offset int64, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	if offset < 0 || offset+size < offset {
+		return ErrIncompleteRead
+	}
+	var last [1]byte
+	if n, _ := fr.Reader.ReadAt(last[:], offset+size-1); n != 1 {
+		return ErrIncompleteRead
 	}
-	fr.Offset += 2
-	return ret
+	return nil
 }
 
-func (fr *BufferReader) ReadUint32() uint32 {
-	var ret uint32
-	err := binary.Read(fr.Reader, fr.ByteOrder, &ret)
+func (fr *BufferReader) ReadInto(limit int64, data interface{}) error {
+	if err := fr.CheckAvailable(fr.Offset, limit); err != nil {
+		return err
+	}
+	buffer := make([]byte, limit)
+	n, err := io.ReadFull(fr, buffer)
+	if err == io.ErrUnexpectedEOF || (err == io.EOF && limit > 0) {
+		return ErrIncompleteRead
+	}
 	if err != nil {
-		panic(err)
+		return err
+	}
+	if err := binary.Read(bytes.NewReader(buffer[:n]), fr.ByteOrder, data); err != nil {
+		return err
 	}
-	fr.Offset += 4
-	return ret
+	return nil
 }
 
-func (fr *BufferReader) ReadBuffer(size int64) []byte {
-	buffer := make([]byte, size)
-	fr.ReadInto(size, &buffer)
-	return buffer
+func (fr *BufferReader) ReadByte() (byte, error) {
+	var ret byte
+	err := fr.ReadInto(1, &ret)
+	return ret, err
+}
+
+func (fr *BufferReader) ReadUint16() (uint16, error) {
+	var ret uint16
+	err := fr.ReadInto(2, &ret)
+	return ret, err
+}
+
+func (fr *BufferReader) ReadUint32() (uint32, error) {
+	var ret uint32
+	err := fr.ReadInto(4, &ret)
+	return ret, err
 }
 
-func (fr *BufferReader) MoveTo(name string, targetOffset int64) {
-	if fr.Offset == targetOffset {
-		return
+func (fr *BufferReader) ReadBuffer(size int64) ([]byte, error) {
+	if err := fr.CheckAvailable(fr.Offset, size); err != nil {
+		return nil, err
 	}
-	if fr.Offset > targetOffset {
-		panic(fmt.Sprintf("moveTo: target offset PASSED ALREADY for %s:%d<%d!\n", name, targetOffset, fr.Offset))
+	buffer := make([]byte, size)
+	if _, err := io.ReadFull(fr, buffer); err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			return nil, ErrIncompleteRead
+		}
+		return nil, err
 	}
+	return buffer, nil
+}
 
-	size := targetOffset - fr.Offset
-	buffer := fr.ReadBuffer(size)
-	for i, b := range buffer {
-		if b != 0x0 {
-			fmt.Printf("Discarded %d bytes while moving to %s:@%d %v\n", size, name, targetOffset, buffer[i:])
-			break
-		}
+func (fr *BufferReader) MoveTo(name string, targetOffset int64) error {
+	if targetOffset < 0 {
+		return fmt.Errorf("moveTo: invalid target offset for %s: %d", name, targetOffset)
 	}
+	fr.Offset = targetOffset
+	return nil
 }
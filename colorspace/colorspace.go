@@ -0,0 +1,216 @@
+package colorspace
+
+import (
+	"fmt"
+	"github.com/lpautet/cr2cv/demosaic"
+	"github.com/lpautet/cr2cv/internal/imageutil"
+	"image"
+	"math"
+	"sync"
+)
+
+// Matrix is a 3x3 matrix applied to column vectors of colour components.
+type Matrix [3][3]float32
+
+var Identity = Matrix{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+func (m Matrix) Mul(n Matrix) Matrix {
+	var r Matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				r[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return r
+}
+
+func (m Matrix) Apply(v [3]float32) [3]float32 {
+	return [3]float32{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+func (m Matrix) Inverse() (Matrix, error) {
+	a := [3][3]float64{}
+	for i := range m {
+		for j := range m[i] {
+			a[i][j] = float64(m[i][j])
+		}
+	}
+	det := a[0][0]*(a[1][1]*a[2][2]-a[1][2]*a[2][1]) -
+		a[0][1]*(a[1][0]*a[2][2]-a[1][2]*a[2][0]) +
+		a[0][2]*(a[1][0]*a[2][1]-a[1][1]*a[2][0])
+	if det == 0 {
+		return Matrix{}, fmt.Errorf("colorspace: singular matrix %v", m)
+	}
+	var r Matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			// cofactor of a[j][i]
+			i1, i2 := (j+1)%3, (j+2)%3
+			j1, j2 := (i+1)%3, (i+2)%3
+			r[i][j] = float32((a[i1][j1]*a[i2][j2] - a[i1][j2]*a[i2][j1]) / det)
+		}
+	}
+	return r, nil
+}
+
+// Space is an RGB working or output colour space.
+type Space int
+
+const (
+	SRGB Space = iota
+	AdobeRGB
+	ProPhotoRGB
+)
+
+func (s Space) String() string {
+	switch s {
+	case SRGB:
+		return "srgb"
+	case AdobeRGB:
+		return "adobergb"
+	case ProPhotoRGB:
+		return "prophoto"
+	}
+	return fmt.Sprintf("Space(%d)", int(s))
+}
+
+func ParseSpace(name string) (Space, error) {
+	for _, s := range []Space{SRGB, AdobeRGB, ProPhotoRGB} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("colorspace: unknown colour space %q", name)
+}
+
+// Linear RGB to XYZ matrices, all relative to a D65 white point. ProPhoto RGB
+// is defined for D50 and goes through a Bradford adaptation.
+var (
+	srgbToXYZ = Matrix{
+		{0.4124564, 0.3575761, 0.1804375},
+		{0.2126729, 0.7151522, 0.0721750},
+		{0.0193339, 0.1191920, 0.9503041},
+	}
+	adobeToXYZ = Matrix{
+		{0.5767309, 0.1855540, 0.1881852},
+		{0.2973769, 0.6273491, 0.0752741},
+		{0.0270343, 0.0706872, 0.9911085},
+	}
+	prophotoToXYZD50 = Matrix{
+		{0.7976749, 0.1351917, 0.0313534},
+		{0.2880402, 0.7118741, 0.0000857},
+		{0.0000000, 0.0000000, 0.8252100},
+	}
+	bradfordD50ToD65 = Matrix{
+		{0.9555766, -0.0230393, 0.0631636},
+		{-0.0282895, 1.0099416, 0.0210077},
+		{0.0122982, -0.0204830, 1.3299098},
+	}
+)
+
+// ToXYZ returns the matrix converting linear values of the space to XYZ (D65).
+func (s Space) ToXYZ() Matrix {
+	switch s {
+	case AdobeRGB:
+		return adobeToXYZ
+	case ProPhotoRGB:
+		return bradfordD50ToD65.Mul(prophotoToXYZD50)
+	}
+	return srgbToXYZ
+}
+
+// FromXYZ returns the matrix converting XYZ (D65) to linear values of the
+// space.
+func (s Space) FromXYZ() Matrix {
+	m, _ := s.ToXYZ().Inverse()
+	return m
+}
+
+// Encode applies the transfer function of the space to a linear value in
+// [0, 1].
+func (s Space) Encode(v float32) float32 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 1
+	}
+	l := float64(v)
+	switch s {
+	case AdobeRGB:
+		return float32(math.Pow(l, 256.0/563))
+	case ProPhotoRGB:
+		if l < 1.0/512 {
+			return float32(16 * l)
+		}
+		return float32(math.Pow(l, 1/1.8))
+	}
+	if l <= 0.0031308 {
+		return float32(12.92 * l)
+	}
+	return float32(1.055*math.Pow(l, 1/2.4) - 0.055)
+}
+
+// Size of the tables used to encode images, indexed by 16 bits linear values
+const curveSize = 1 << 16
+
+var (
+	curvesOnce sync.Once
+	curves     map[Space][]uint16
+)
+
+func (s Space) curve() []uint16 {
+	curvesOnce.Do(func() {
+		curves = make(map[Space][]uint16)
+		for _, space := range []Space{SRGB, AdobeRGB, ProPhotoRGB} {
+			c := make([]uint16, curveSize)
+			for i := range c {
+				c[i] = uint16(space.Encode(float32(i)/(curveSize-1))*0xffff + 0.5)
+			}
+			curves[space] = c
+		}
+	})
+	return curves[s]
+}
+
+// Convert multiplies every pixel of img by m, in place.
+func Convert(img *demosaic.RGB, m Matrix, workers int) {
+	height := img.Rect.Dy()
+	width := img.Rect.Dx()
+	imageutil.ParallelFor(height, workers, func(y int) {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			p := row[3*x : 3*x+3]
+			v := m.Apply([3]float32{p[0], p[1], p[2]})
+			p[0], p[1], p[2] = v[0], v[1], v[2]
+		}
+	})
+}
+
+// EncodeImage returns the display referred 16 bits version of the linear
+// image, clipping values outside of [0, 1].
+func (s Space) EncodeImage(img *demosaic.RGB, workers int) *image.RGBA64 {
+	curve := s.curve()
+	out := image.NewRGBA64(img.Rect)
+	width := img.Rect.Dx()
+	imageutil.ParallelFor(img.Rect.Dy(), workers, func(y int) {
+		row := img.Pix[y*img.Stride:]
+		dst := out.Pix[y*out.Stride:]
+		for x := 0; x < width; x++ {
+			for c := 0; c < 3; c++ {
+				v := curve[imageutil.To16(row[3*x+c])]
+				dst[8*x+2*c] = uint8(v >> 8)
+				dst[8*x+2*c+1] = uint8(v)
+			}
+			dst[8*x+6] = 0xff
+			dst[8*x+7] = 0xff
+		}
+	})
+	return out
+}
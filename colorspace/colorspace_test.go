@@ -0,0 +1,142 @@
+package colorspace
+
+import (
+	"math"
+	"testing"
+)
+
+func closeTo(a, b float32, tolerance float64) bool {
+	return math.Abs(float64(a)-float64(b)) <= tolerance
+}
+
+func TestInverse(t *testing.T) {
+	matrices := []Matrix{
+		Identity,
+		srgbToXYZ,
+		adobeToXYZ,
+		prophotoToXYZD50,
+		bradfordD50ToD65,
+		{{2, 0, 0}, {0, 0, 3}, {0, -1, 0}},
+		// Canon EOS 5D Mark III XYZ to camera matrix
+		{{0.6722, -0.0635, -0.0963}, {-0.4287, 1.2460, 0.2028}, {-0.0908, 0.2162, 0.5668}},
+	}
+	for _, m := range matrices {
+		inverse, err := m.Inverse()
+		if err != nil {
+			t.Errorf("%v: %v", m, err)
+			continue
+		}
+		for _, p := range []Matrix{m.Mul(inverse), inverse.Mul(m)} {
+			for i := range p {
+				for j := range p[i] {
+					if !closeTo(p[i][j], Identity[i][j], 1e-5) {
+						t.Errorf("%v times its inverse %v gives %v", m, inverse, p)
+					}
+				}
+			}
+		}
+	}
+	if _, err := (Matrix{{1, 2, 3}, {2, 4, 6}, {0, 0, 1}}).Inverse(); err == nil {
+		t.Error("no error inverting a singular matrix")
+	}
+}
+
+// XYZ of the D50 and D65 white points.
+var (
+	whiteD50 = [3]float32{0.96422, 1, 0.82521}
+	whiteD65 = [3]float32{0.95047, 1, 1.08883}
+)
+
+func TestBradford(t *testing.T) {
+	// Bradford cone response matrix
+	ma := Matrix{
+		{0.8951, 0.2664, -0.1614},
+		{-0.7502, 1.7135, 0.0367},
+		{0.0389, -0.0685, 1.0296},
+	}
+	maInverse, err := ma.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, dst := ma.Apply(whiteD50), ma.Apply(whiteD65)
+	scale := Matrix{{dst[0] / src[0], 0, 0}, {0, dst[1] / src[1], 0}, {0, 0, dst[2] / src[2]}}
+	want := maInverse.Mul(scale).Mul(ma)
+	for i := range want {
+		for j := range want[i] {
+			if !closeTo(bradfordD50ToD65[i][j], want[i][j], 1e-5) {
+				t.Fatalf("Bradford D50 to D65 is %v, want %v", bradfordD50ToD65, want)
+			}
+		}
+	}
+	// the values published by Bruce Lindbloom
+	lindbloom := Matrix{
+		{0.9555766, -0.0230393, 0.0631636},
+		{-0.0282895, 1.0099416, 0.0210077},
+		{0.0122982, -0.0204830, 1.3299098},
+	}
+	if bradfordD50ToD65 != lindbloom {
+		t.Errorf("Bradford D50 to D65 is %v, want %v", bradfordD50ToD65, lindbloom)
+	}
+	white := bradfordD50ToD65.Apply(whiteD50)
+	for c := range white {
+		if !closeTo(white[c], whiteD65[c], 1e-4) {
+			t.Errorf("D50 white adapted to %v, want %v", white, whiteD65)
+			break
+		}
+	}
+}
+
+// The white of every space is the D65 white once converted to XYZ.
+func TestSpaceWhite(t *testing.T) {
+	for _, s := range []Space{SRGB, AdobeRGB, ProPhotoRGB} {
+		white := s.ToXYZ().Apply([3]float32{1, 1, 1})
+		for c := range white {
+			if !closeTo(white[c], whiteD65[c], 1e-3) {
+				t.Errorf("%v: white is %v in XYZ, want %v", s, white, whiteD65)
+				break
+			}
+		}
+		back := s.FromXYZ().Apply(white)
+		for c := range back {
+			if !closeTo(back[c], 1, 1e-5) {
+				t.Errorf("%v: XYZ white %v converted back to %v", s, white, back)
+				break
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		space Space
+		v     float32
+		want  float32
+	}{
+		{SRGB, -0.5, 0},
+		{SRGB, 0, 0},
+		{SRGB, 1, 1},
+		{SRGB, 2, 1},
+		// end of the linear segment, continuous with the power curve
+		{SRGB, 0.0031308, 0.0404499},
+		{SRGB, 0.0031307, 12.92 * 0.0031307},
+		{SRGB, 0.0031309, float32(1.055*math.Pow(0.0031309, 1/2.4) - 0.055)},
+		{SRGB, 0.18, 0.4613561},
+		// Adobe RGB (1998) has no linear segment
+		{AdobeRGB, 0, 0},
+		{AdobeRGB, 1, 1},
+		{AdobeRGB, 0.0031308, float32(math.Pow(0.0031308, 256.0/563))},
+		{AdobeRGB, 0.18, float32(math.Pow(0.18, 256.0/563))},
+		// ProPhoto RGB: 16 times the value below 1/512, where both curves
+		// give 1/32
+		{ProPhotoRGB, 0, 0},
+		{ProPhotoRGB, 1, 1},
+		{ProPhotoRGB, 1.0 / 1024, 16.0 / 1024},
+		{ProPhotoRGB, 1.0 / 512, 1.0 / 32},
+		{ProPhotoRGB, 0.18, float32(math.Pow(0.18, 1/1.8))},
+	}
+	for _, test := range tests {
+		if got := test.space.Encode(test.v); !closeTo(got, test.want, 1e-6) {
+			t.Errorf("%v.Encode(%v) = %v, want %v", test.space, test.v, got, test.want)
+		}
+	}
+}
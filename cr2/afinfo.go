@@ -0,0 +1,158 @@
+package cr2
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// AFInfo describes the AF points recorded in Exif.Canon.AFInfo2.
+type AFInfo struct {
+	AreaMode CanonAFAreaMode
+	// ValidPoints is the number of points usable in the AF area mode
+	ValidPoints int
+	// Width and Height are the size of the image the points are located in
+	Width  int
+	Height int
+	Points []AFPoint
+}
+
+// AFPoint is an AF point, its area in image coordinates.
+type AFPoint struct {
+	Rect     image.Rectangle
+	InFocus  bool
+	Selected bool
+}
+
+func decodeAFInfo2(values []uint16) (*AFInfo, error) {
+	if len(values) < 8 {
+		return nil, fmt.Errorf("AFInfo2 too short: %d values", len(values))
+	}
+	a := canonArray(values)
+	n := a.at(2)
+	// widths, heights, x and y positions, then the in focus bits
+	masks := (n + 15) / 16
+	if n < 0 || len(values) < 8+4*n+masks {
+		return nil, fmt.Errorf("AFInfo2 too short for %d points: %d values", n, len(values))
+	}
+	af := &AFInfo{
+		AreaMode:    CanonAFAreaMode(a.at(1)),
+		ValidPoints: a.at(3),
+		Width:       a.at(4),
+		Height:      a.at(5),
+		Points:      make([]AFPoint, n),
+	}
+	afWidth, afHeight := a.at(6), a.at(7)
+	if afWidth <= 0 || afHeight <= 0 {
+		afWidth, afHeight = af.Width, af.Height
+	}
+	if af.Width <= 0 || af.Height <= 0 {
+		af.Width, af.Height = afWidth, afHeight
+	}
+	if afWidth <= 0 || afHeight <= 0 {
+		return nil, fmt.Errorf("invalid AF image size %dx%d", afWidth, afHeight)
+	}
+	inFocus := 8 + 4*n
+	selected := inFocus + masks
+	for i := range af.Points {
+		w, h := a.at(8+i), a.at(8+n+i)
+		// positions are of the centre of the point, from the centre of the
+		// image with Y upwards
+		x := afWidth/2 + a.at(8+2*n+i) - w/2
+		y := afHeight/2 - a.at(8+3*n+i) - h/2
+		af.Points[i] = AFPoint{
+			Rect: image.Rect(x*af.Width/afWidth, y*af.Height/afHeight,
+				(x+w)*af.Width/afWidth, (y+h)*af.Height/afHeight),
+			InFocus:  values[inFocus+i/16]&(1<<uint(i%16)) != 0,
+			Selected: selected+i/16 < len(values) && values[selected+i/16]&(1<<uint(i%16)) != 0,
+		}
+	}
+	return af, nil
+}
+
+// AFInfo decodes the Canon AFInfo2 tag.
+func (cf *CR2File) AFInfo() (*AFInfo, error) {
+	values, err := cf.makerNodeSubIfd.uint16ArrayTag(ExifCanonAFInfo2)
+	if err != nil {
+		return nil, err
+	}
+	af, err := decodeAFInfo2(values)
+	if err != nil {
+		entry := cf.makerNodeSubIfd.TagsById[ExifCanonAFInfo2]
+		return nil, wrapError(int64(entry.DataOrOffset), GetCanonTagName(ExifCanonAFInfo2), err)
+	}
+	return af, nil
+}
+
+// InFocus returns the points that were in focus.
+func (af *AFInfo) InFocus() []AFPoint {
+	var points []AFPoint
+	for _, p := range af.Points {
+		if p.InFocus {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// Overlay returns a copy of img with the outline of the points in focus drawn
+// over it, the points being scaled from the AF image size to the bounds of
+// img.
+func (af *AFInfo) Overlay(img image.Image, c color.Color) *image.RGBA {
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+	if af.Width <= 0 || af.Height <= 0 {
+		return out
+	}
+	thickness := bounds.Dx() / 500
+	if thickness < 1 {
+		thickness = 1
+	}
+	src := image.NewUniform(c)
+	for _, p := range af.InFocus() {
+		r := image.Rect(
+			p.Rect.Min.X*bounds.Dx()/af.Width, p.Rect.Min.Y*bounds.Dy()/af.Height,
+			p.Rect.Max.X*bounds.Dx()/af.Width, p.Rect.Max.Y*bounds.Dy()/af.Height,
+		).Add(bounds.Min)
+		inner := r.Inset(thickness)
+		for _, side := range []image.Rectangle{
+			image.Rect(r.Min.X, r.Min.Y, r.Max.X, inner.Min.Y),
+			image.Rect(r.Min.X, inner.Max.Y, r.Max.X, r.Max.Y),
+			image.Rect(r.Min.X, inner.Min.Y, inner.Min.X, inner.Max.Y),
+			image.Rect(inner.Max.X, inner.Min.Y, r.Max.X, inner.Max.Y),
+		} {
+			draw.Draw(out, side.Intersect(bounds), src, image.ZP, draw.Src)
+		}
+	}
+	return out
+}
+
+// AFPreview returns the preview image with the AF points in focus outlined
+// in red.
+func (cf *CR2File) AFPreview() (*image.RGBA, error) {
+	af, err := cf.AFInfo()
+	if err != nil {
+		return nil, err
+	}
+	img, err := cf.Preview()
+	if err != nil {
+		return nil, err
+	}
+	return af.Overlay(img, color.RGBA{R: 0xff, A: 0xff}), nil
+}
+
+type CanonAFAreaMode int
+
+var canonAFAreaModes = map[int]string{
+	0: "Off (Manual Focus)", 1: "AF Point Expansion (surround)", 2: "Single-point AF",
+	4: "Auto or Multi-point AF", 5: "Face Detect AF", 6: "Face + Tracking", 7: "Zone AF",
+	8: "AF Point Expansion (4 point)", 9: "Spot AF", 10: "AF Point Expansion (8 point)",
+	11: "Flexizone Multi (49 point)", 12: "Flexizone Multi (9 point)", 13: "Flexizone Single",
+	14: "Large Zone AF",
+}
+
+func (v CanonAFAreaMode) String() string {
+	return enumName(canonAFAreaModes, "CanonAFAreaMode", int(v))
+}
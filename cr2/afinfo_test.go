@@ -0,0 +1,79 @@
+package cr2
+
+import (
+	"image"
+	"testing"
+)
+
+// afInfo2 returns an AFInfo2 value of three points: one at the centre, one
+// left of and below it and one right of and above it, the second one in
+// focus and the first two selected, in Single-point AF.
+func afInfo2(width, height, afWidth, afHeight int) []uint16 {
+	signed := func(v int) uint16 { return uint16(int16(v)) }
+	return []uint16{
+		0, 2, 3, 3, uint16(width), uint16(height), uint16(afWidth), uint16(afHeight),
+		// widths, heights
+		100, 60, 60, 80, 60, 60,
+		// x and y positions
+		0, signed(-500), 700, 0, signed(-300), 400,
+		// in focus, selected
+		0x2, 0x3,
+	}
+}
+
+func TestDecodeAFInfo2(t *testing.T) {
+	// the AF image is half the size of the image
+	af, err := decodeAFInfo2(afInfo2(5760, 3840, 2880, 1920))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if af.AreaMode.String() != "Single-point AF" || af.ValidPoints != 3 || af.Width != 5760 || af.Height != 3840 {
+		t.Errorf("got area mode %v, %d valid points, %dx%d, want Single-point AF, 3, 5760x3840", af.AreaMode, af.ValidPoints, af.Width, af.Height)
+	}
+	want := []AFPoint{
+		// top left corner at (1440 - 50, 960 - 40) in the AF image
+		{Rect: image.Rect(2780, 1840, 2980, 2000), Selected: true},
+		// (1440 - 500 - 30, 960 + 300 - 30)
+		{Rect: image.Rect(1820, 2460, 1940, 2580), InFocus: true, Selected: true},
+		// (1440 + 700 - 30, 960 - 400 - 30)
+		{Rect: image.Rect(4220, 1060, 4340, 1180)},
+	}
+	if len(af.Points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(af.Points), len(want))
+	}
+	for i, p := range af.Points {
+		if p != want[i] {
+			t.Errorf("point %d: got %+v, want %+v", i, p, want[i])
+		}
+	}
+	if inFocus := af.InFocus(); len(inFocus) != 1 || inFocus[0] != want[1] {
+		t.Errorf("in focus: got %+v, want %+v", inFocus, want[1:2])
+	}
+
+	// without an AF image size, the positions are in the image
+	af, err = decodeAFInfo2(afInfo2(2880, 1920, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := af.Points[1].Rect; r != image.Rect(910, 1230, 970, 1290) {
+		t.Errorf("point 1: got %v, want %v", r, image.Rect(910, 1230, 970, 1290))
+	}
+}
+
+func TestDecodeAFInfo2Invalid(t *testing.T) {
+	values := afInfo2(5760, 3840, 2880, 1920)
+	tests := map[string][]uint16{
+		"header only":      values[:8],
+		"no in focus bits": values[:8+4*3],
+		"no size":          afInfo2(0, 0, 0, 0),
+	}
+	for name, values := range tests {
+		if _, err := decodeAFInfo2(values); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	// the selected bits are optional
+	if _, err := decodeAFInfo2(values[:8+4*3+1]); err != nil {
+		t.Errorf("without selected bits: %v", err)
+	}
+}
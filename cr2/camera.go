@@ -0,0 +1,174 @@
+package cr2
+
+import (
+	"fmt"
+	"github.com/lpautet/cr2cv/colorspace"
+	"strings"
+)
+
+// CanonModelNames maps Exif.Canon.CanonModelID to the model name, as written
+// in Exif.Image.Model.
+var CanonModelNames = map[uint32]string{
+	0x80000001: "Canon EOS-1D",
+	0x80000167: "Canon EOS-1DS",
+	0x80000168: "Canon EOS 10D",
+	0x80000169: "Canon EOS-1D Mark III",
+	0x80000170: "Canon EOS 300D DIGITAL",
+	0x80000174: "Canon EOS-1D Mark II",
+	0x80000175: "Canon EOS 20D",
+	0x80000176: "Canon EOS 450D",
+	0x80000188: "Canon EOS-1Ds Mark II",
+	0x80000189: "Canon EOS 350D DIGITAL",
+	0x80000190: "Canon EOS 40D",
+	0x80000213: "Canon EOS 5D",
+	0x80000215: "Canon EOS-1Ds Mark III",
+	0x80000218: "Canon EOS 5D Mark II",
+	0x80000232: "Canon EOS-1D Mark II N",
+	0x80000234: "Canon EOS 30D",
+	0x80000236: "Canon EOS 400D DIGITAL",
+	0x80000250: "Canon EOS 7D",
+	0x80000252: "Canon EOS 500D",
+	0x80000254: "Canon EOS 1000D",
+	0x80000261: "Canon EOS 50D",
+	0x80000269: "Canon EOS-1D X",
+	0x80000270: "Canon EOS 550D",
+	0x80000281: "Canon EOS-1D Mark IV",
+	0x80000285: "Canon EOS 5D Mark III",
+	0x80000286: "Canon EOS 600D",
+	0x80000287: "Canon EOS 60D",
+	0x80000288: "Canon EOS 1100D",
+	0x80000289: "Canon EOS 7D Mark II",
+	0x80000301: "Canon EOS 650D",
+	0x80000302: "Canon EOS 6D",
+	0x80000325: "Canon EOS 70D",
+	0x80000326: "Canon EOS 700D",
+	0x80000327: "Canon EOS 1200D",
+	0x80000331: "Canon EOS M",
+	0x80000346: "Canon EOS 100D",
+	0x80000349: "Canon EOS 5D Mark IV",
+	0x80000350: "Canon EOS 80D",
+	0x80000382: "Canon EOS 5DS",
+	0x80000401: "Canon EOS 5DS R",
+}
+
+type cameraInfo struct {
+	// raw value at which the sensor saturates at base ISO, black included
+	whiteLevel uint16
+	// XYZ (D65) to camera RGB matrix scaled by 10000, as published by Adobe
+	// in the DNG converter
+	matrix [9]int16
+}
+
+var cameras = map[string]cameraInfo{
+	"Canon EOS-1D Mark II N": {0xe80, [9]int16{6240, -466, -822, -8180, 15825, 2500, -1801, 1938, 8042}},
+	"Canon EOS-1D Mark III":  {0x3bb0, [9]int16{6291, -540, -976, -8350, 16145, 2311, -1714, 1858, 7326}},
+	"Canon EOS-1D Mark IV":   {0x3bb0, [9]int16{6014, -220, -795, -4109, 12014, 2361, -561, 1824, 5787}},
+	"Canon EOS-1D X":         {0x3c4e, [9]int16{6847, -614, -1014, -4669, 12737, 2139, -1197, 2488, 6846}},
+	"Canon EOS-1Ds Mark II":  {0xe80, [9]int16{6517, -602, -867, -8180, 15926, 2378, -1618, 1771, 7633}},
+	"Canon EOS-1Ds Mark III": {0x3bb0, [9]int16{5859, -211, -930, -8255, 16017, 2353, -1732, 1887, 7448}},
+	"Canon EOS 5D":           {0xe6c, [9]int16{6347, -479, -972, -8297, 15954, 2480, -1968, 2131, 7649}},
+	"Canon EOS 5D Mark II":   {0x3cf0, [9]int16{4716, 603, -830, -7798, 15474, 2480, -1496, 1937, 6651}},
+	"Canon EOS 5D Mark III":  {0x3c80, [9]int16{6722, -635, -963, -4287, 12460, 2028, -908, 2162, 5668}},
+	"Canon EOS 5D Mark IV":   {0x3ffc, [9]int16{6446, -366, -864, -4436, 12204, 2513, -952, 2496, 6348}},
+	"Canon EOS 5DS":          {0x3c96, [9]int16{6250, -711, -808, -5153, 12794, 2636, -1249, 2198, 5610}},
+	"Canon EOS 5DS R":        {0x3c96, [9]int16{6250, -711, -808, -5153, 12794, 2636, -1249, 2198, 5610}},
+	"Canon EOS 6D":           {0x3c82, [9]int16{7034, -804, -1014, -4420, 12564, 2058, -851, 1994, 5758}},
+	"Canon EOS 7D":           {0x3510, [9]int16{6844, -996, -856, -3876, 11761, 2396, -593, 1772, 6198}},
+	"Canon EOS 7D Mark II":   {0x3510, [9]int16{7268, -1082, -969, -4186, 11839, 2663, -825, 2029, 5839}},
+	"Canon EOS 10D":          {0xfa0, [9]int16{8197, -2000, -1118, -6714, 14335, 2592, -2536, 3178, 8266}},
+	"Canon EOS 20D":          {0xfff, [9]int16{6599, -537, -891, -8071, 15783, 2424, -1983, 2234, 7462}},
+	"Canon EOS 30D":          {0xfff, [9]int16{6257, -303, -1000, -7880, 15621, 2396, -1714, 1904, 7046}},
+	"Canon EOS 40D":          {0x3f60, [9]int16{6071, -747, -856, -7653, 15365, 2441, -2025, 2553, 7315}},
+	"Canon EOS 50D":          {0x3d93, [9]int16{4920, 616, -593, -6493, 13964, 2784, -1774, 3178, 7005}},
+	"Canon EOS 60D":          {0x2ff7, [9]int16{6719, -994, -925, -4408, 12426, 2211, -887, 2129, 6051}},
+	"Canon EOS 70D":          {0x3bc7, [9]int16{7034, -804, -1014, -4420, 12564, 2058, -851, 1994, 5758}},
+	"Canon EOS 80D":          {0x3ffc, [9]int16{7457, -671, -937, -4849, 12495, 2643, -1213, 2354, 5492}},
+	"Canon EOS 100D":         {0x350f, [9]int16{6602, -841, -939, -4472, 12458, 2247, -975, 2039, 6148}},
+	"Canon EOS 300D DIGITAL": {0xfa0, [9]int16{8197, -2000, -1118, -6714, 14335, 2592, -2536, 3178, 8266}},
+	"Canon EOS 350D DIGITAL": {0xfff, [9]int16{6018, -617, -965, -8645, 15881, 2975, -1530, 1719, 7642}},
+	"Canon EOS 400D DIGITAL": {0xe8e, [9]int16{7054, -1501, -990, -8156, 15544, 2812, -1278, 1414, 7796}},
+	"Canon EOS 450D":         {0x390d, [9]int16{5784, -262, -821, -7539, 15064, 2672, -1982, 2681, 7427}},
+	"Canon EOS 500D":         {0x3479, [9]int16{4763, 712, -646, -6821, 14399, 2640, -1921, 3276, 6561}},
+	"Canon EOS 550D":         {0x3dd7, [9]int16{6941, -1164, -857, -3825, 11597, 2534, -416, 1540, 6039}},
+	"Canon EOS 600D":         {0x3510, [9]int16{6461, -907, -882, -4300, 12184, 2378, -819, 1944, 5931}},
+	"Canon EOS 650D":         {0x354d, [9]int16{6602, -841, -939, -4472, 12458, 2247, -975, 2039, 6148}},
+	"Canon EOS 700D":         {0x3c00, [9]int16{6602, -841, -939, -4472, 12458, 2247, -975, 2039, 6148}},
+	"Canon EOS 1000D":        {0xe43, [9]int16{6771, -1139, -977, -7818, 15123, 2928, -1244, 1437, 7533}},
+	"Canon EOS 1100D":        {0x3510, [9]int16{6444, -904, -893, -4563, 12308, 2535, -903, 2016, 6728}},
+	"Canon EOS 1200D":        {0x37c2, [9]int16{6461, -907, -882, -4300, 12184, 2378, -819, 1944, 5931}},
+	"Canon EOS M":            {0x3ffc, [9]int16{6602, -841, -939, -4472, 12458, 2247, -975, 2039, 6148}},
+}
+
+// Model returns the camera model name, from the Canon model ID when known
+// and from Exif.Image.Model otherwise.
+func (cf *CR2File) Model() string {
+	if id, err := cf.makerNodeSubIfd.uintTag(ExifCanonModelID); err == nil {
+		if name, ok := CanonModelNames[id]; ok {
+			return name
+		}
+	}
+	if entry := cf.ifd0.TagsById[ExifImageModel]; entry != nil && entry.TagType == TagTypeString {
+		return strings.TrimSpace(entry.StringValue(cf))
+	}
+	return ""
+}
+
+// whiteLevel returns the saturation level of the samples of the shot: the
+// specular white level of ColorData when recorded and below the decoded
+// level, lower at the intermediate ISOs reached by digital gain, and the
+// level of the model at base ISO otherwise.
+func (cf *CR2File) whiteLevel(decoded uint16) uint16 {
+	if cd, err := cf.ColorData(); err == nil && cd.SpecularWhiteLevel != 0 && cd.SpecularWhiteLevel < decoded {
+		return cd.SpecularWhiteLevel
+	}
+	return modelWhiteLevel(cf.Model(), decoded)
+}
+
+// modelWhiteLevel returns the saturation level of the samples of a model,
+// the level of the model at base ISO when known and below the decoded one.
+func modelWhiteLevel(model string, decoded uint16) uint16 {
+	if camera, ok := cameras[model]; ok && camera.whiteLevel != 0 && camera.whiteLevel < decoded {
+		return camera.whiteLevel
+	}
+	return decoded
+}
+
+// CameraMatrix returns the XYZ (D65) to camera RGB matrix of the model.
+func (cf *CR2File) CameraMatrix() (colorspace.Matrix, error) {
+	model := cf.Model()
+	camera, ok := cameras[model]
+	if !ok {
+		return colorspace.Matrix{}, fmt.Errorf("cr2: no colour matrix for model %q", model)
+	}
+	var m colorspace.Matrix
+	for i, c := range camera.matrix {
+		m[i/3][i%3] = float32(c) / 10000
+	}
+	return m, nil
+}
+
+// CameraToSpace returns the matrix converting white balanced camera RGB to
+// linear values of the given space, camera white mapping to the space white.
+func (cf *CR2File) CameraToSpace(space colorspace.Space) (colorspace.Matrix, error) {
+	camXYZ, err := cf.CameraMatrix()
+	if err != nil {
+		return colorspace.Matrix{}, err
+	}
+	// camera response to the sRGB primaries, normalized so that sRGB white
+	// gives equal camera values once white balanced
+	camRGB := camXYZ.Mul(colorspace.SRGB.ToXYZ())
+	for i := range camRGB {
+		sum := camRGB[i][0] + camRGB[i][1] + camRGB[i][2]
+		if sum == 0 {
+			return colorspace.Matrix{}, fmt.Errorf("cr2: invalid colour matrix for model %q", cf.Model())
+		}
+		for j := range camRGB[i] {
+			camRGB[i][j] /= sum
+		}
+	}
+	rgbCam, err := camRGB.Inverse()
+	if err != nil {
+		return colorspace.Matrix{}, err
+	}
+	return space.FromXYZ().Mul(colorspace.SRGB.ToXYZ()).Mul(rgbCam), nil
+}
@@ -0,0 +1,120 @@
+package cr2
+
+import (
+	"github.com/lpautet/cr2cv/colorspace"
+	"testing"
+)
+
+func TestModelWhiteLevel(t *testing.T) {
+	tests := []struct {
+		model   string
+		decoded uint16
+		want    uint16
+	}{
+		{"Canon EOS 5D Mark III", 0x3fff, 0x3c80},
+		{"Canon EOS 5D Mark III", 0x3000, 0x3000},
+		{"Canon EOS 20D", 0xfff, 0xfff},
+		{"Canon EOS 10D", 0xfff, 0xfa0},
+		{"Canon EOS 5D", 0x3fff, 0xe6c},
+		{"Canon EOS-1D", 0xfff, 0xfff},
+		{"", 0x3fff, 0x3fff},
+	}
+	for _, test := range tests {
+		if got := modelWhiteLevel(test.model, test.decoded); got != test.want {
+			t.Errorf("modelWhiteLevel(%q, %#x) = %#x, want %#x", test.model, test.decoded, got, test.want)
+		}
+	}
+}
+
+// maxBlackLevel returns the highest black level measured on the masked
+// border of the test file.
+func maxBlackLevel(t *testing.T, cf *CR2File) uint16 {
+	full, err := cf.FullRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := cf.SensorInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	black, ok := full.MeasureBlackLevel(si.BlackMask)
+	if !ok {
+		t.Fatal("no black level measured")
+	}
+	max := black[0]
+	for _, b := range black {
+		if b > max {
+			max = b
+		}
+	}
+	return max
+}
+
+// The test file has no white levels in ColorData: the model level is used.
+func TestRawWhiteLevel(t *testing.T) {
+	cf := openBytes(t, readFixture(t))
+	raw, err := cf.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := modelWhiteLevel(cf.Model(), 0x3fff) - maxBlackLevel(t, cf); raw.WhiteLevel != want {
+		t.Errorf("WhiteLevel = %#x, want %#x", raw.WhiteLevel, want)
+	}
+}
+
+func TestRawWhiteLevelFromColorData(t *testing.T) {
+	cf := openBytes(t, readFixture(t))
+	values, err := cf.makerNodeSubIfd.uint16ArrayTag(ExifCanonColorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ColorData7 version 10, below the 12000 samples of the saturated
+	// square of the test file and above all the others
+	values = append([]uint16(nil), values...)
+	values[0x2dc], values[0x2dd] = 11000, 11500
+	cf.MakerNoteIFD().SetUint16(ExifCanonColorData, values...)
+	cd, err := cf.ColorData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cd.NormalWhiteLevel != 11000 || cd.SpecularWhiteLevel != 11500 {
+		t.Fatalf("white levels %d and %d, want 11000 and 11500", cd.NormalWhiteLevel, cd.SpecularWhiteLevel)
+	}
+	raw, err := cf.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 11500 - maxBlackLevel(t, cf); raw.WhiteLevel != want {
+		t.Errorf("WhiteLevel = %#x, want %#x", raw.WhiteLevel, want)
+	}
+	mask := raw.ClippingMask()
+	for y := raw.Rect.Min.Y; y < raw.Rect.Max.Y; y++ {
+		for x := raw.Rect.Min.X; x < raw.Rect.Max.X; x++ {
+			want := x >= 30 && x < 44 && y >= 10 && y < 24
+			if clipped := mask.AlphaAt(x, y).A != 0; clipped != want {
+				t.Fatalf("pixel (%d, %d) clipped: %v, want %v", x, y, clipped, want)
+			}
+		}
+	}
+}
+
+// White balanced camera white is the white of every space, for every model.
+func TestCameraToSpaceWhite(t *testing.T) {
+	cf := openBytes(t, readFixture(t))
+	cf.MakerNoteIFD().DeleteTag(ExifCanonModelID)
+	for model := range cameras {
+		cf.IFD0().SetString(ExifImageModel, model)
+		for _, space := range []colorspace.Space{colorspace.SRGB, colorspace.AdobeRGB, colorspace.ProPhotoRGB} {
+			m, err := cf.CameraToSpace(space)
+			if err != nil {
+				t.Errorf("%s, %v: %v", model, space, err)
+				continue
+			}
+			for i, row := range m {
+				if sum := row[0] + row[1] + row[2]; sum < 0.9999 || sum > 1.0001 {
+					t.Errorf("%s, %v: row %d sums to %v", model, space, i, sum)
+				}
+			}
+		}
+	}
+}
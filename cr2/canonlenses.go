@@ -0,0 +1,241 @@
+package cr2
+
+// canonLenses maps the Canon lens types of CameraSettings to lens names, after
+// ExifTool. Third party lenses reuse the types of Canon lenses.
+var canonLenses = []canonLens{
+	{1, "Canon EF 50mm f/1.8"},
+	{2, "Canon EF 28mm f/2.8"},
+	{2, "Sigma 24mm f/2.8 Super Wide II"},
+	{3, "Canon EF 135mm f/2.8 Soft"},
+	{4, "Canon EF 35-105mm f/3.5-4.5"},
+	{4, "Sigma UC Zoom 35-135mm f/4-5.6"},
+	{5, "Canon EF 35-70mm f/3.5-4.5"},
+	{6, "Canon EF 28-70mm f/3.5-4.5"},
+	{6, "Sigma 18-50mm f/3.5-5.6 DC"},
+	{6, "Tokina AF 193-2 19-35mm f/3.5-4.5"},
+	{7, "Canon EF 100-300mm f/5.6L"},
+	{8, "Canon EF 100-300mm f/5.6"},
+	{8, "Sigma 70-300mm f/4-5.6 DG Macro"},
+	{9, "Canon EF 70-210mm f/4"},
+	{10, "Canon EF 50mm f/2.5 Macro"},
+	{10, "Sigma 50mm f/2.8 EX"},
+	{11, "Canon EF 35mm f/2"},
+	{13, "Canon EF 15mm f/2.8 Fisheye"},
+	{14, "Canon EF 50-200mm f/3.5-4.5L"},
+	{15, "Canon EF 50-200mm f/3.5-4.5"},
+	{16, "Canon EF 35-135mm f/3.5-4.5"},
+	{17, "Canon EF 35-70mm f/3.5-4.5A"},
+	{18, "Canon EF 28-70mm f/3.5-4.5"},
+	{20, "Canon EF 100-200mm f/4.5A"},
+	{21, "Canon EF 80-200mm f/2.8L"},
+	{22, "Canon EF 20-35mm f/2.8L"},
+	{23, "Canon EF 35-105mm f/3.5-4.5"},
+	{24, "Canon EF 35-80mm f/4-5.6 Power Zoom"},
+	{25, "Canon EF 35-80mm f/4-5.6 Power Zoom"},
+	{26, "Canon EF 100mm f/2.8 Macro"},
+	{27, "Canon EF 35-80mm f/4-5.6"},
+	{28, "Canon EF 80-200mm f/4.5-5.6"},
+	{29, "Canon EF 50mm f/1.8 II"},
+	{30, "Canon EF 35-105mm f/4.5-5.6"},
+	{31, "Canon EF 75-300mm f/4-5.6"},
+	{32, "Canon EF 24mm f/2.8"},
+	{35, "Canon EF 35-80mm f/4-5.6"},
+	{36, "Canon EF 38-76mm f/4.5-5.6"},
+	{37, "Canon EF 35-80mm f/4-5.6"},
+	{38, "Canon EF 80-200mm f/4.5-5.6 II"},
+	{39, "Canon EF 75-300mm f/4-5.6"},
+	{40, "Canon EF 28-80mm f/3.5-5.6"},
+	{41, "Canon EF 28-90mm f/4-5.6"},
+	{42, "Canon EF 28-200mm f/3.5-5.6"},
+	{43, "Canon EF 28-105mm f/4-5.6"},
+	{44, "Canon EF 90-300mm f/4.5-5.6"},
+	{45, "Canon EF-S 18-55mm f/3.5-5.6"},
+	{46, "Canon EF 28-90mm f/4-5.6"},
+	{48, "Canon EF-S 18-55mm f/3.5-5.6 IS"},
+	{49, "Canon EF-S 55-250mm f/4-5.6 IS"},
+	{50, "Canon EF-S 18-200mm f/3.5-5.6 IS"},
+	{51, "Canon EF-S 18-135mm f/3.5-5.6 IS"},
+	{52, "Canon EF-S 18-55mm f/3.5-5.6 IS II"},
+	{53, "Canon EF-S 18-55mm f/3.5-5.6 III"},
+	{54, "Canon EF-S 55-250mm f/4-5.6 IS II"},
+	{94, "Canon TS-E 17mm f/4L"},
+	{95, "Canon TS-E 24mm f/3.5L II"},
+	{124, "Canon MP-E 65mm f/2.8 1-5x Macro Photo"},
+	{125, "Canon TS-E 24mm f/3.5L"},
+	{126, "Canon TS-E 45mm f/2.8"},
+	{127, "Canon TS-E 90mm f/2.8"},
+	{129, "Canon EF 300mm f/2.8L USM"},
+	{130, "Canon EF 50mm f/1.0L USM"},
+	{131, "Canon EF 28-80mm f/2.8-4L USM"},
+	{131, "Sigma 8mm f/3.5 EX DG Circular Fisheye"},
+	{132, "Canon EF 1200mm f/5.6L USM"},
+	{134, "Canon EF 600mm f/4L IS USM"},
+	{135, "Canon EF 200mm f/1.8L USM"},
+	{136, "Canon EF 300mm f/2.8L USM"},
+	{137, "Canon EF 85mm f/1.2L USM"},
+	{137, "Sigma 18-50mm f/2.8-4.5 DC OS HSM"},
+	{137, "Sigma 50-200mm f/4-5.6 DC OS HSM"},
+	{137, "Sigma 17-70mm f/2.8-4 DC Macro OS HSM"},
+	{138, "Canon EF 28-80mm f/2.8-4L"},
+	{139, "Canon EF 400mm f/2.8L USM"},
+	{140, "Canon EF 500mm f/4.5L USM"},
+	{141, "Canon EF 500mm f/4.5L USM"},
+	{142, "Canon EF 300mm f/2.8L IS USM"},
+	{143, "Canon EF 500mm f/4L IS USM"},
+	{144, "Canon EF 35-135mm f/4-5.6 USM"},
+	{145, "Canon EF 100-300mm f/4.5-5.6 USM"},
+	{146, "Canon EF 70-210mm f/3.5-4.5 USM"},
+	{147, "Canon EF 35-135mm f/4-5.6 USM"},
+	{148, "Canon EF 28-80mm f/3.5-5.6 USM"},
+	{149, "Canon EF 100mm f/2 USM"},
+	{150, "Canon EF 14mm f/2.8L USM"},
+	{150, "Sigma 20mm f/1.8 EX DG"},
+	{150, "Sigma 30mm f/1.4 DC HSM"},
+	{151, "Canon EF 200mm f/2.8L USM"},
+	{152, "Canon EF 300mm f/4L IS USM"},
+	{152, "Sigma 12-24mm f/4.5-5.6 EX DG ASPHERICAL HSM"},
+	{153, "Canon EF 35-350mm f/3.5-5.6L USM"},
+	{153, "Sigma 50-500mm f/4-6.3 APO HSM EX"},
+	{154, "Canon EF 20mm f/2.8 USM"},
+	{155, "Canon EF 85mm f/1.8 USM"},
+	{156, "Canon EF 28-105mm f/3.5-4.5 USM"},
+	{160, "Canon EF 20-35mm f/3.5-4.5 USM"},
+	{160, "Tokina AT-X 124 AF Pro DX 12-24mm f/4"},
+	{161, "Canon EF 28-70mm f/2.8L USM"},
+	{161, "Sigma 24-70mm f/2.8 EX"},
+	{161, "Tamron SP AF 28-75mm f/2.8 XR Di LD Aspherical [IF] Macro"},
+	{162, "Canon EF 200mm f/2.8L USM"},
+	{163, "Canon EF 300mm f/4L"},
+	{164, "Canon EF 400mm f/5.6L"},
+	{165, "Canon EF 70-200mm f/2.8L USM"},
+	{166, "Canon EF 70-200mm f/2.8L USM + 1.4x"},
+	{167, "Canon EF 70-200mm f/2.8L USM + 2x"},
+	{168, "Canon EF 28mm f/1.8 USM"},
+	{169, "Canon EF 17-35mm f/2.8L USM"},
+	{169, "Sigma 18-200mm f/3.5-6.3 DC OS"},
+	{169, "Sigma 15-30mm f/3.5-4.5 EX DG Aspherical"},
+	{170, "Canon EF 200mm f/2.8L II USM"},
+	{171, "Canon EF 300mm f/4L USM"},
+	{172, "Canon EF 400mm f/5.6L USM"},
+	{173, "Canon EF 180mm Macro f/3.5L USM"},
+	{173, "Sigma 150mm f/2.8 EX DG APO HSM Macro"},
+	{174, "Canon EF 135mm f/2L USM"},
+	{174, "Sigma 70-200mm f/2.8 EX DG APO OS HSM"},
+	{175, "Canon EF 400mm f/2.8L USM"},
+	{176, "Canon EF 24-85mm f/3.5-4.5 USM"},
+	{177, "Canon EF 300mm f/4L IS USM"},
+	{178, "Canon EF 28-135mm f/3.5-5.6 IS"},
+	{179, "Canon EF 24mm f/1.4L USM"},
+	{180, "Canon EF 35mm f/1.4L USM"},
+	{180, "Sigma 50mm f/1.4 DG HSM | A"},
+	{181, "Canon EF 100-400mm f/4.5-5.6L IS USM + 1.4x"},
+	{182, "Canon EF 100-400mm f/4.5-5.6L IS USM + 2x"},
+	{183, "Canon EF 100-400mm f/4.5-5.6L IS USM"},
+	{183, "Sigma 150mm f/2.8 EX DG OS HSM APO Macro"},
+	{184, "Canon EF 400mm f/2.8L USM + 2x"},
+	{185, "Canon EF 600mm f/4L IS USM"},
+	{186, "Canon EF 70-200mm f/4L USM"},
+	{187, "Canon EF 70-200mm f/4L USM + 1.4x"},
+	{188, "Canon EF 70-200mm f/4L USM + 2x"},
+	{189, "Canon EF 70-200mm f/4L USM + 2.8x"},
+	{190, "Canon EF 100mm f/2.8 Macro USM"},
+	{191, "Canon EF 400mm f/4 DO IS"},
+	{193, "Canon EF 35-80mm f/4-5.6 USM"},
+	{194, "Canon EF 80-200mm f/4.5-5.6 USM"},
+	{195, "Canon EF 35-105mm f/4.5-5.6 USM"},
+	{196, "Canon EF 75-300mm f/4-5.6 USM"},
+	{197, "Canon EF 75-300mm f/4-5.6 IS USM"},
+	{198, "Canon EF 50mm f/1.4 USM"},
+	{198, "Zeiss Otus 55mm f/1.4 ZE"},
+	{199, "Canon EF 28-80mm f/3.5-5.6 USM"},
+	{200, "Canon EF 75-300mm f/4-5.6 USM"},
+	{201, "Canon EF 28-80mm f/3.5-5.6 USM"},
+	{202, "Canon EF 28-80mm f/3.5-5.6 USM IV"},
+	{208, "Canon EF 22-55mm f/4-5.6 USM"},
+	{209, "Canon EF 55-200mm f/4.5-5.6"},
+	{210, "Canon EF 28-90mm f/4-5.6 USM"},
+	{211, "Canon EF 28-200mm f/3.5-5.6 USM"},
+	{212, "Canon EF 28-105mm f/4-5.6 USM"},
+	{213, "Canon EF 90-300mm f/4.5-5.6 USM"},
+	{214, "Canon EF-S 18-55mm f/3.5-5.6 USM"},
+	{215, "Canon EF 55-200mm f/4.5-5.6 II USM"},
+	{224, "Canon EF 70-200mm f/2.8L IS USM"},
+	{225, "Canon EF 70-200mm f/2.8L IS USM + 1.4x"},
+	{226, "Canon EF 70-200mm f/2.8L IS USM + 2x"},
+	{227, "Canon EF 70-200mm f/2.8L IS USM + 2.8x"},
+	{228, "Canon EF 28-105mm f/3.5-4.5 USM"},
+	{229, "Canon EF 16-35mm f/2.8L USM"},
+	{230, "Canon EF 24-70mm f/2.8L USM"},
+	{231, "Canon EF 17-40mm f/4L USM"},
+	{232, "Canon EF 70-300mm f/4.5-5.6 DO IS USM"},
+	{233, "Canon EF 28-300mm f/3.5-5.6L IS USM"},
+	{234, "Canon EF-S 17-85mm f/4-5.6 IS USM"},
+	{235, "Canon EF-S 10-22mm f/3.5-4.5 USM"},
+	{236, "Canon EF-S 60mm f/2.8 Macro USM"},
+	{237, "Canon EF 24-105mm f/4L IS USM"},
+	{238, "Canon EF 70-300mm f/4-5.6 IS USM"},
+	{239, "Canon EF 85mm f/1.2L II USM"},
+	{240, "Canon EF-S 17-55mm f/2.8 IS USM"},
+	{241, "Canon EF 50mm f/1.2L USM"},
+	{242, "Canon EF 70-200mm f/4L IS USM"},
+	{243, "Canon EF 70-200mm f/4L IS USM + 1.4x"},
+	{244, "Canon EF 70-200mm f/4L IS USM + 2x"},
+	{245, "Canon EF 70-200mm f/4L IS USM + 2.8x"},
+	{246, "Canon EF 16-35mm f/2.8L II USM"},
+	{247, "Canon EF 14mm f/2.8L II USM"},
+	{248, "Canon EF 200mm f/2L IS USM"},
+	{249, "Canon EF 800mm f/5.6L IS USM"},
+	{250, "Canon EF 24mm f/1.4L II USM"},
+	{251, "Canon EF 70-200mm f/2.8L IS II USM"},
+	{252, "Canon EF 70-200mm f/2.8L IS II USM + 1.4x"},
+	{253, "Canon EF 70-200mm f/2.8L IS II USM + 2x"},
+	{254, "Canon EF 100mm f/2.8L Macro IS USM"},
+	{255, "Sigma 24-105mm f/4 DG OS HSM | A"},
+	{488, "Canon EF-S 15-85mm f/3.5-5.6 IS USM"},
+	{489, "Canon EF 70-300mm f/4-5.6L IS USM"},
+	{490, "Canon EF 8-15mm f/4L Fisheye USM"},
+	{491, "Canon EF 300mm f/2.8L IS II USM"},
+	{492, "Canon EF 400mm f/2.8L IS II USM"},
+	{493, "Canon EF 500mm f/4L IS II USM"},
+	{493, "Canon EF 24-105mm f/4L IS USM"},
+	{494, "Canon EF 600mm f/4L IS II USM"},
+	{495, "Canon EF 24-70mm f/2.8L II USM"},
+	{496, "Canon EF 200-400mm f/4L IS USM"},
+	{499, "Canon EF 200-400mm f/4L IS USM + 1.4x"},
+	{502, "Canon EF 28mm f/2.8 IS USM"},
+	{503, "Canon EF 24mm f/2.8 IS USM"},
+	{504, "Canon EF 24-70mm f/4L IS USM"},
+	{505, "Canon EF 35mm f/2 IS USM"},
+	{506, "Canon EF 400mm f/4 DO IS II USM"},
+	{507, "Canon EF 16-35mm f/4L IS USM"},
+	{508, "Canon EF 11-24mm f/4L USM"},
+	{747, "Canon EF 100-400mm f/4.5-5.6L IS II USM"},
+	{748, "Canon EF 100-400mm f/4.5-5.6L IS II USM + 1.4x"},
+	{750, "Canon EF 35mm f/1.4L II USM"},
+	{751, "Canon EF 16-35mm f/2.8L III USM"},
+	{752, "Canon EF 24-105mm f/4L IS II USM"},
+	{753, "Canon EF 85mm f/1.4L IS USM"},
+	{754, "Canon EF 70-200mm f/4L IS II USM"},
+	{757, "Canon EF 400mm f/2.8L IS III USM"},
+	{758, "Canon EF 600mm f/4L IS III USM"},
+	{4142, "Canon EF-S 18-135mm f/3.5-5.6 IS STM"},
+	{4143, "Canon EF-M 18-55mm f/3.5-5.6 IS STM"},
+	{4144, "Canon EF 40mm f/2.8 STM"},
+	{4145, "Canon EF-M 22mm f/2 STM"},
+	{4146, "Canon EF-S 18-55mm f/3.5-5.6 IS STM"},
+	{4147, "Canon EF-M 11-22mm f/4-5.6 IS STM"},
+	{4148, "Canon EF-S 55-250mm f/4-5.6 IS STM"},
+	{4149, "Canon EF-M 55-200mm f/4.5-6.3 IS STM"},
+	{4150, "Canon EF-S 10-18mm f/4.5-5.6 IS STM"},
+	{4152, "Canon EF 24-105mm f/3.5-5.6 IS STM"},
+	{4153, "Canon EF-M 15-45mm f/3.5-6.3 IS STM"},
+	{4154, "Canon EF-S 24mm f/2.8 STM"},
+	{4155, "Canon EF-M 28mm f/3.5 Macro IS STM"},
+	{4156, "Canon EF 50mm f/1.8 STM"},
+	{4157, "Canon EF-M 18-150mm f/3.5-6.3 IS STM"},
+	{4158, "Canon EF-S 18-55mm f/4-5.6 IS STM"},
+	{4159, "Canon EF-M 32mm f/1.4 STM"},
+	{4160, "Canon EF-S 35mm f/2.8 Macro IS STM"},
+	{36910, "Canon EF 70-300mm f/4-5.6 IS II USM"},
+	{36912, "Canon EF-S 18-135mm f/3.5-5.6 IS USM"},
+}
@@ -0,0 +1,445 @@
+package cr2
+
+import (
+	"fmt"
+	"math"
+)
+
+// CameraSettings holds the decoded values of Exif.Canon.CameraSettings.
+type CameraSettings struct {
+	MacroMode CanonMacroMode
+	// SelfTimer delay in seconds, 0 when off
+	SelfTimer       float64
+	Quality         CanonQuality
+	FlashMode       CanonFlashMode
+	ContinuousDrive CanonDriveMode
+	FocusMode       CanonFocusMode
+	RecordMode      CanonRecordMode
+	ImageSize       CanonImageSize
+	EasyMode        CanonEasyMode
+	DigitalZoom     CanonDigitalZoom
+	Contrast        int
+	Saturation      int
+	Sharpness       int
+	// ISO is 0 for auto ISO
+	ISO          int
+	MeteringMode CanonMeteringMode
+	FocusRange   CanonFocusRange
+	AFPoint      CanonAFPoint
+	ExposureMode CanonExposureMode
+	LensType     int
+	// MinFocalLength and MaxFocalLength in millimeters
+	MinFocalLength float64
+	MaxFocalLength float64
+	// MaxAperture and MinAperture as f-numbers
+	MaxAperture     float64
+	MinAperture     float64
+	FocusContinuous CanonFocusContinuous
+	AESetting       CanonAESetting
+	SRAWQuality     CanonSRAWQuality
+}
+
+// ShotInfo holds the decoded values of Exif.Canon.ShotInfo.
+type ShotInfo struct {
+	// AutoISO is the factor, in percent, applied to BaseISO by auto ISO
+	AutoISO    float64
+	BaseISO    float64
+	MeasuredEV float64
+	// TargetAperture as an f-number and TargetExposureTime in seconds, as
+	// chosen by the exposure program
+	TargetAperture       float64
+	TargetExposureTime   float64
+	ExposureCompensation float64
+	WhiteBalance         CanonWhiteBalance
+	SlowShutter          CanonSlowShutter
+	SequenceNumber       int
+	// CameraTemperature in degrees Celsius, 0 when not recorded
+	CameraTemperature      int
+	FlashGuideNumber       float64
+	AFPointsInFocus        int
+	FlashExposureComp      float64
+	AutoExposureBracketing CanonAutoExposureBracketing
+	AEBBracketValue        float64
+	// FocusDistanceUpper and FocusDistanceLower in meters
+	FocusDistanceUpper float64
+	FocusDistanceLower float64
+	FNumber            float64
+	// ExposureTime and BulbDuration in seconds
+	ExposureTime float64
+	BulbDuration float64
+	CameraType   CanonCameraType
+	AutoRotate   CanonAutoRotate
+}
+
+// ISO returns the sensitivity the image was taken at.
+func (si *ShotInfo) ISO() float64 {
+	return si.BaseISO * si.AutoISO / 100
+}
+
+// canonArray gives the signed values of a Canon maker note array, 0 past its
+// end. The first value is the size of the array in bytes.
+type canonArray []uint16
+
+func (a canonArray) at(i int) int {
+	if i >= len(a) {
+		return 0
+	}
+	return int(int16(a[i]))
+}
+
+// canonEV converts a Canon exposure value, in 1/32 EV with thirds stored as
+// 0x0c and 0x14, to EV.
+func canonEV(v int) float64 {
+	sign := 1.0
+	if v < 0 {
+		sign, v = -1, -v
+	}
+	frac := float64(v & 0x1f)
+	switch frac {
+	case 0x0c:
+		frac = 32.0 / 3
+	case 0x14:
+		frac = 64.0 / 3
+	}
+	return sign * (float64(v&^0x1f) + frac) / 32
+}
+
+// canonAperture returns the f-number of a Canon aperture value, 0 for 0.
+func canonAperture(v int) float64 {
+	if v == 0 {
+		return 0
+	}
+	return math.Exp2(canonEV(v) / 2)
+}
+
+func decodeCameraSettings(values []uint16) (*CameraSettings, error) {
+	if len(values) < 2 {
+		return nil, fmt.Errorf("CameraSettings too short: %d values", len(values))
+	}
+	a := canonArray(values)
+	cs := &CameraSettings{
+		MacroMode:       CanonMacroMode(a.at(1)),
+		SelfTimer:       float64(a.at(2)&0x3fff) / 10,
+		Quality:         CanonQuality(a.at(3)),
+		FlashMode:       CanonFlashMode(a.at(4)),
+		ContinuousDrive: CanonDriveMode(a.at(5)),
+		FocusMode:       CanonFocusMode(a.at(7)),
+		RecordMode:      CanonRecordMode(a.at(9)),
+		ImageSize:       CanonImageSize(a.at(10)),
+		EasyMode:        CanonEasyMode(a.at(11)),
+		DigitalZoom:     CanonDigitalZoom(a.at(12)),
+		Contrast:        a.at(13),
+		Saturation:      a.at(14),
+		Sharpness:       a.at(15),
+		MeteringMode:    CanonMeteringMode(a.at(17)),
+		FocusRange:      CanonFocusRange(a.at(18)),
+		AFPoint:         CanonAFPoint(a.at(19) & 0xffff),
+		ExposureMode:    CanonExposureMode(a.at(20)),
+		LensType:        int(uint16(a.at(22))),
+		MaxAperture:     canonAperture(a.at(26)),
+		MinAperture:     canonAperture(a.at(27)),
+		FocusContinuous: CanonFocusContinuous(a.at(32)),
+		AESetting:       CanonAESetting(a.at(33)),
+		SRAWQuality:     CanonSRAWQuality(a.at(46)),
+	}
+	// 0x4000 flags an ISO value, older models store an index
+	iso := a.at(16)
+	switch {
+	case iso&0x4000 != 0:
+		cs.ISO = iso & 0x3fff
+	case iso >= 16 && iso <= 19:
+		cs.ISO = 50 << uint(iso-16)
+	}
+	if units := a.at(25); units > 0 {
+		cs.MaxFocalLength = float64(uint16(a.at(23))) / float64(units)
+		cs.MinFocalLength = float64(uint16(a.at(24))) / float64(units)
+	}
+	return cs, nil
+}
+
+func decodeShotInfo(values []uint16) (*ShotInfo, error) {
+	if len(values) < 2 {
+		return nil, fmt.Errorf("ShotInfo too short: %d values", len(values))
+	}
+	a := canonArray(values)
+	si := &ShotInfo{
+		AutoISO:                math.Exp2(float64(a.at(1))/32) * 100,
+		BaseISO:                math.Exp2(float64(a.at(2))/32) * 100 / 32,
+		MeasuredEV:             float64(a.at(3))/32 + 5,
+		TargetAperture:         canonAperture(a.at(4)),
+		ExposureCompensation:   canonEV(a.at(6)),
+		WhiteBalance:           CanonWhiteBalance(a.at(7)),
+		SlowShutter:            CanonSlowShutter(a.at(8)),
+		SequenceNumber:         a.at(9),
+		FlashGuideNumber:       float64(a.at(13)) / 32,
+		AFPointsInFocus:        a.at(14) & 0xffff,
+		FlashExposureComp:      canonEV(a.at(15)),
+		AutoExposureBracketing: CanonAutoExposureBracketing(a.at(16)),
+		AEBBracketValue:        canonEV(a.at(17)),
+		FocusDistanceUpper:     float64(uint16(a.at(19))) / 100,
+		FocusDistanceLower:     float64(uint16(a.at(20))) / 100,
+		FNumber:                canonAperture(a.at(21)),
+		BulbDuration:           float64(a.at(24)) / 10,
+		CameraType:             CanonCameraType(a.at(26)),
+		AutoRotate:             CanonAutoRotate(a.at(27)),
+	}
+	if v := a.at(5); v != 0 {
+		si.TargetExposureTime = math.Exp2(-canonEV(v))
+	}
+	if v := a.at(22); v != 0 {
+		si.ExposureTime = math.Exp2(-canonEV(v))
+	}
+	if v := a.at(12); v != 0 {
+		si.CameraTemperature = v - 128
+	}
+	return si, nil
+}
+
+// CameraSettings decodes the Canon CameraSettings tag.
+func (cf *CR2File) CameraSettings() (*CameraSettings, error) {
+	values, err := cf.makerNodeSubIfd.uint16ArrayTag(ExifCanonCameraSettings)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := decodeCameraSettings(values)
+	if err != nil {
+		entry := cf.makerNodeSubIfd.TagsById[ExifCanonCameraSettings]
+		return nil, wrapError(int64(entry.DataOrOffset), GetCanonTagName(ExifCanonCameraSettings), err)
+	}
+	return cs, nil
+}
+
+// ShotInfo decodes the Canon ShotInfo tag.
+func (cf *CR2File) ShotInfo() (*ShotInfo, error) {
+	values, err := cf.makerNodeSubIfd.uint16ArrayTag(ExifCanonShotInfo)
+	if err != nil {
+		return nil, err
+	}
+	si, err := decodeShotInfo(values)
+	if err != nil {
+		entry := cf.makerNodeSubIfd.TagsById[ExifCanonShotInfo]
+		return nil, wrapError(int64(entry.DataOrOffset), GetCanonTagName(ExifCanonShotInfo), err)
+	}
+	return si, nil
+}
+
+// The enumerations below follow the values documented by ExifTool, String
+// giving their name or the type and value when unknown.
+
+type CanonMacroMode int
+type CanonQuality int
+type CanonFlashMode int
+type CanonDriveMode int
+type CanonFocusMode int
+type CanonRecordMode int
+type CanonImageSize int
+type CanonEasyMode int
+type CanonDigitalZoom int
+type CanonMeteringMode int
+type CanonFocusRange int
+type CanonAFPoint int
+type CanonExposureMode int
+type CanonFocusContinuous int
+type CanonAESetting int
+type CanonSRAWQuality int
+type CanonWhiteBalance int
+type CanonSlowShutter int
+type CanonAutoExposureBracketing int
+type CanonCameraType int
+type CanonAutoRotate int
+
+func enumName(names map[int]string, typeName string, v int) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("%s(%d)", typeName, v)
+}
+
+var canonMacroModes = map[int]string{1: "Macro", 2: "Normal"}
+
+func (v CanonMacroMode) String() string {
+	return enumName(canonMacroModes, "CanonMacroMode", int(v))
+}
+
+var canonQualities = map[int]string{
+	-1: "n/a", 0: "Unknown", 1: "Economy", 2: "Normal", 3: "Fine", 4: "RAW", 5: "Superfine", 7: "CRAW",
+	130: "Light (RAW)", 131: "Standard (RAW)",
+}
+
+func (v CanonQuality) String() string {
+	return enumName(canonQualities, "CanonQuality", int(v))
+}
+
+var canonFlashModes = map[int]string{
+	-1: "n/a", 0: "Off", 1: "Auto", 2: "On", 3: "Red-eye reduction", 4: "Slow-sync",
+	5: "Red-eye reduction (Auto)", 6: "Red-eye reduction (On)", 16: "External flash",
+}
+
+func (v CanonFlashMode) String() string {
+	return enumName(canonFlashModes, "CanonFlashMode", int(v))
+}
+
+var canonDriveModes = map[int]string{
+	0: "Single", 1: "Continuous", 2: "Movie", 3: "Continuous, Speed Priority", 4: "Continuous, Low",
+	5: "Continuous, High", 6: "Silent Single", 8: "Continuous, High+", 9: "Single, Silent",
+	10: "Continuous, Silent",
+}
+
+func (v CanonDriveMode) String() string {
+	return enumName(canonDriveModes, "CanonDriveMode", int(v))
+}
+
+var canonFocusModes = map[int]string{
+	0: "One-shot AF", 1: "AI Servo AF", 2: "AI Focus AF", 3: "Manual Focus (3)", 4: "Single", 5: "Continuous",
+	6: "Manual Focus (6)", 16: "Pan Focus", 256: "One-shot AF (Live View)", 257: "AI Servo AF (Live View)",
+	258: "AI Focus AF (Live View)", 512: "Movie Snap Focus", 519: "Movie Servo AF",
+}
+
+func (v CanonFocusMode) String() string {
+	return enumName(canonFocusModes, "CanonFocusMode", int(v))
+}
+
+var canonRecordModes = map[int]string{
+	1: "JPEG", 2: "CRW+THM", 3: "AVI+THM", 4: "TIF", 5: "TIF+JPEG", 6: "CR2", 7: "CR2+JPEG", 9: "MOV",
+	10: "MP4", 11: "CRM", 12: "CR3", 13: "CR3+JPEG", 14: "HIF", 15: "CR3+HIF",
+}
+
+func (v CanonRecordMode) String() string {
+	return enumName(canonRecordModes, "CanonRecordMode", int(v))
+}
+
+var canonImageSizes = map[int]string{
+	-1: "n/a", 0: "Large", 1: "Medium", 2: "Small", 5: "Medium 1", 6: "Medium 2", 7: "Medium 3",
+	8: "Postcard", 9: "Widescreen", 10: "Medium Widescreen", 14: "Small 1", 15: "Small 2", 16: "Small 3",
+	128: "640x480 Movie", 129: "Medium Movie", 130: "Small Movie", 137: "1280x720 Movie",
+	142: "1920x1080 Movie", 143: "4096x2160 Movie",
+}
+
+func (v CanonImageSize) String() string {
+	return enumName(canonImageSizes, "CanonImageSize", int(v))
+}
+
+var canonEasyModes = map[int]string{
+	0: "Full auto", 1: "Manual", 2: "Landscape", 3: "Fast shutter", 4: "Slow shutter", 5: "Night",
+	6: "Gray Scale", 7: "Sepia", 8: "Portrait", 9: "Sports", 10: "Macro", 11: "Black & White",
+	12: "Pan focus", 13: "Vivid", 14: "Neutral", 15: "Flash Off", 16: "Long Shutter",
+	17: "Super Macro", 18: "Foliage", 19: "Indoor", 20: "Fireworks", 21: "Beach", 22: "Underwater",
+	23: "Snow", 24: "Kids & Pets", 25: "Night Snapshot", 26: "Digital Macro", 27: "My Colors",
+	28: "Movie Snap", 29: "Super Macro 2", 30: "Color Accent", 31: "Color Swap", 32: "Aquarium",
+	33: "ISO 3200", 34: "ISO 6400", 35: "Creative Light Effect", 36: "Easy", 37: "Quick Shot",
+	38: "Creative Auto", 39: "Zoom Blur", 40: "Low Light", 41: "Nostalgic", 42: "Super Vivid",
+	43: "Poster Effect", 44: "Face Self-timer", 45: "Smile", 46: "Wink Self-timer", 47: "Fisheye Effect",
+	48: "Miniature Effect", 49: "High-speed Burst", 50: "Best Image Selection", 51: "High Dynamic Range",
+	52: "Handheld Night Scene", 53: "Movie Digest", 54: "Live View Control", 55: "Discreet",
+	56: "Blur Reduction", 57: "Monochrome", 58: "Toy Camera Effect", 59: "Scene Intelligent Auto",
+	60: "High-speed Burst HQ", 61: "Smooth Skin", 62: "Soft Focus", 257: "Spotlight", 258: "Night 2",
+	259: "Night+", 260: "Super Night", 261: "Sunset", 263: "Night Scene", 264: "Surface",
+	265: "Low Light 2",
+}
+
+func (v CanonEasyMode) String() string {
+	return enumName(canonEasyModes, "CanonEasyMode", int(v))
+}
+
+var canonDigitalZooms = map[int]string{0: "None", 1: "2x", 2: "4x", 3: "Other"}
+
+func (v CanonDigitalZoom) String() string {
+	return enumName(canonDigitalZooms, "CanonDigitalZoom", int(v))
+}
+
+var canonMeteringModes = map[int]string{
+	0: "Default", 1: "Spot", 2: "Average", 3: "Evaluative", 4: "Partial", 5: "Center-weighted average",
+}
+
+func (v CanonMeteringMode) String() string {
+	return enumName(canonMeteringModes, "CanonMeteringMode", int(v))
+}
+
+var canonFocusRanges = map[int]string{
+	0: "Manual", 1: "Auto", 2: "Not Known", 3: "Macro", 4: "Very Close", 5: "Close", 6: "Middle Range",
+	7: "Far Range", 8: "Pan Focus", 9: "Super Macro", 10: "Infinity",
+}
+
+func (v CanonFocusRange) String() string {
+	return enumName(canonFocusRanges, "CanonFocusRange", int(v))
+}
+
+var canonAFPoints = map[int]string{
+	0x2005: "Manual AF point selection", 0x3000: "None (MF)", 0x3001: "Auto AF point selection",
+	0x3002: "Right", 0x3003: "Center", 0x3004: "Left", 0x4001: "Auto AF point selection",
+	0x4006: "Face Detect",
+}
+
+func (v CanonAFPoint) String() string {
+	return enumName(canonAFPoints, "CanonAFPoint", int(v))
+}
+
+var canonExposureModes = map[int]string{
+	0: "Easy", 1: "Program AE", 2: "Shutter speed priority AE", 3: "Aperture-priority AE", 4: "Manual",
+	5: "Depth-of-field AE", 6: "M-Dep", 7: "Bulb", 8: "Flexible-priority AE",
+}
+
+func (v CanonExposureMode) String() string {
+	return enumName(canonExposureModes, "CanonExposureMode", int(v))
+}
+
+var canonFocusContinuous = map[int]string{0: "Single", 1: "Continuous", 8: "Manual"}
+
+func (v CanonFocusContinuous) String() string {
+	return enumName(canonFocusContinuous, "CanonFocusContinuous", int(v))
+}
+
+var canonAESettings = map[int]string{
+	0: "Normal AE", 1: "Exposure Compensation", 2: "AE Lock", 3: "AE Lock + Exposure Comp.", 4: "No AE",
+}
+
+func (v CanonAESetting) String() string {
+	return enumName(canonAESettings, "CanonAESetting", int(v))
+}
+
+var canonSRAWQualities = map[int]string{0: "n/a", 1: "sRAW1 (mRAW)", 2: "sRAW2 (sRAW)"}
+
+func (v CanonSRAWQuality) String() string {
+	return enumName(canonSRAWQualities, "CanonSRAWQuality", int(v))
+}
+
+var canonWhiteBalances = map[int]string{
+	0: "Auto", 1: "Daylight", 2: "Cloudy", 3: "Tungsten", 4: "Fluorescent", 5: "Flash", 6: "Custom",
+	7: "Black & White", 8: "Shade", 9: "Manual Temperature (Kelvin)", 10: "PC Set1", 11: "PC Set2",
+	12: "PC Set3", 14: "Daylight Fluorescent", 15: "Custom 1", 16: "Custom 2", 17: "Underwater",
+	18: "Custom 3", 19: "Custom 4", 20: "PC Set4", 21: "PC Set5", 23: "Auto (ambience priority)",
+}
+
+func (v CanonWhiteBalance) String() string {
+	return enumName(canonWhiteBalances, "CanonWhiteBalance", int(v))
+}
+
+var canonSlowShutters = map[int]string{
+	-1: "n/a", 0: "Off", 1: "Night Scene", 2: "On", 3: "None",
+}
+
+func (v CanonSlowShutter) String() string {
+	return enumName(canonSlowShutters, "CanonSlowShutter", int(v))
+}
+
+var canonAutoExposureBracketings = map[int]string{-1: "On", 0: "Off", 1: "On (shot 1)", 2: "On (shot 2)", 3: "On (shot 3)"}
+
+func (v CanonAutoExposureBracketing) String() string {
+	return enumName(canonAutoExposureBracketings, "CanonAutoExposureBracketing", int(v))
+}
+
+var canonCameraTypes = map[int]string{
+	0: "n/a", 248: "EOS High-end", 250: "Compact", 252: "EOS Mid-range", 255: "DV Camera",
+}
+
+func (v CanonCameraType) String() string {
+	return enumName(canonCameraTypes, "CanonCameraType", int(v))
+}
+
+var canonAutoRotates = map[int]string{
+	-1: "n/a", 0: "None", 1: "Rotate 90 CW", 2: "Rotate 180", 3: "Rotate 270 CW",
+}
+
+func (v CanonAutoRotate) String() string {
+	return enumName(canonAutoRotates, "CanonAutoRotate", int(v))
+}
@@ -0,0 +1,34 @@
+package cr2
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCanonEV(t *testing.T) {
+	tests := []struct {
+		v    int
+		want float64
+	}{
+		{0, 0},
+		{0x20, 1},
+		{0x10, 0.5},
+		{0x08, 0.25},
+		// thirds
+		{0x0c, 1.0 / 3},
+		{0x14, 2.0 / 3},
+		{0x2c, 4.0 / 3},
+		{0x54, 8.0 / 3},
+		{-0x0c, -1.0 / 3},
+		{-0x34, -5.0 / 3},
+		{-0x20, -1},
+		// aperture of f/5.6 and exposure time of 1/250 s in ShotInfo
+		{0xa0, 5},
+		{0x100, 8},
+	}
+	for _, test := range tests {
+		if got := canonEV(test.v); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("canonEV(%#x) = %v, want %v", test.v, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package cr2
+
+import (
+	"fmt"
+)
+
+// Names of the white balance presets found in ColorData
+const (
+	WBAuto        = "Auto"
+	WBMeasured    = "Measured"
+	WBDaylight    = "Daylight"
+	WBShade       = "Shade"
+	WBCloudy      = "Cloudy"
+	WBTungsten    = "Tungsten"
+	WBFluorescent = "Fluorescent"
+	WBKelvin      = "Kelvin"
+	WBFlash       = "Flash"
+	WBCustom1     = "Custom1"
+	WBCustom2     = "Custom2"
+)
+
+// WhiteBalance is a set of Canon white balance levels, in R, G, G, B order,
+// with the matching colour temperature in Kelvin (0 when unknown).
+type WhiteBalance struct {
+	Levels      [4]uint16
+	Temperature uint16
+}
+
+// Multipliers returns the red, green and blue multipliers of the levels,
+// normalized to a green multiplier of 1.
+func (wb WhiteBalance) Multipliers() [3]float32 {
+	green := (float32(wb.Levels[1]) + float32(wb.Levels[2])) / 2
+	if green == 0 {
+		return [3]float32{1, 1, 1}
+	}
+	return [3]float32{float32(wb.Levels[0]) / green, 1, float32(wb.Levels[3]) / green}
+}
+
+// ColorData holds the white balance part of the Exif.Canon.ColorData block.
+type ColorData struct {
+	// Version is the first value of the block, only meaningful from the
+	// 1D Mark II N / 5D generation on
+	Version int16
+	Count   int
+	AsShot  WhiteBalance
+	// Presets are keyed by the WB* names, the custom ones being only
+	// recorded by some versions (see colorDataLayout)
+	Presets map[string]WhiteBalance
+	// SRAWLevels are the levels sRAW and mRAW pixels are scaled by, zero
+	// when the block has none
+	SRAWLevels [4]uint16
+	// NormalWhiteLevel and SpecularWhiteLevel are the raw levels, black
+	// included, of diffuse white and of sensor saturation for the ISO of the
+	// shot, zero when the version does not record them
+	NormalWhiteLevel   uint16
+	SpecularWhiteLevel uint16
+}
+
+// colorDataLayout lists the white balance records of a ColorData version.
+// Records are 5 values long (4 levels and a temperature) and follow each
+// other from first; an empty name skips an unknown record.
+//
+// Custom white balances are at known positions in ColorData1 (two presets)
+// and ColorData3 (one, after the three PC presets) only. The later versions
+// hold more records than named ones, but where their custom white balance
+// lies is not documented, so it is not decoded and WBCustom1 and WBCustom2
+// are missing from their presets.
+//
+// whiteLevels gives, per Version, the position of the normal white level,
+// which is followed by the specular one. Both come after the four per channel
+// black levels, and are only known for some ColorData4 and ColorData7
+// versions.
+type colorDataLayout struct {
+	first       int
+	records     []string
+	whiteLevels map[int16]int
+}
+
+const wbAsShot = "AsShot"
+
+// The sRAW levels are the first record after the as shot one with this green
+// level.
+const srawGreenLevel = 1170
+
+var (
+	// ColorData1: 20D, 350D
+	colorDataV1 = colorDataLayout{0x19, []string{wbAsShot, WBAuto, WBDaylight, WBShade, WBCloudy, WBTungsten, WBFluorescent, WBFlash, WBCustom1, WBCustom2}, nil}
+	// ColorData2: 1D Mark II, 1Ds Mark II
+	colorDataV2 = colorDataLayout{0x18, []string{WBAuto, "", wbAsShot, WBDaylight, WBShade, WBCloudy, WBTungsten, WBFluorescent, WBKelvin, WBFlash}, nil}
+	// ColorData3: 1D Mark II N, 5D, 30D, 400D
+	colorDataV3 = colorDataLayout{0x3f, []string{wbAsShot, WBAuto, WBMeasured, WBDaylight, WBShade, WBCloudy, WBTungsten, WBFluorescent, WBKelvin, WBFlash, "", "", "", WBCustom1}, nil}
+	// ColorData4: 1D Mark III, 40D, 450D, 1000D, 50D, 5D Mark II, 500D, 7D
+	colorDataV4 = colorDataLayout{0x3f, []string{wbAsShot, WBAuto, WBMeasured, "", WBDaylight, WBShade, WBCloudy, WBTungsten, WBFluorescent, WBKelvin, WBFlash},
+		map[int16]int{4: 0x2b8, 5: 0x2b8, 6: 0x2bd, 7: 0x2bd, 9: 0x2cf}}
+	// ColorData5: PowerShot G10, G11, S90...
+	colorDataV5 = colorDataLayout{0x47, []string{wbAsShot}, nil}
+	// ColorData6: 600D, 1100D
+	colorDataV6 = colorDataLayout{0x3f, []string{wbAsShot, WBAuto, WBMeasured, "", "", "", "", "", WBDaylight, WBShade, WBCloudy, WBTungsten, WBFluorescent, WBKelvin, WBFlash}, nil}
+	// ColorData7: 1D X, 5D Mark III, 6D, 70D, 650D, 700D
+	colorDataV7 = colorDataLayout{0x3f, []string{wbAsShot, WBAuto, WBMeasured, "", "", "", "", "", "", "", "", "", "", WBDaylight, WBShade, WBCloudy, WBTungsten, WBFluorescent, WBKelvin, WBFlash},
+		map[int16]int{10: 0x2dc, 11: 0x2ee}}
+	// ColorData8: 5DS, 7D Mark II, 750D, 760D, 80D
+	colorDataV8 = colorDataLayout{0x3f, []string{wbAsShot, WBAuto, WBMeasured, "", "", "", "", "", "", "", "", "", "", "", WBDaylight, WBShade, WBCloudy, WBTungsten, WBFluorescent, WBKelvin, WBFlash}, nil}
+	// ColorData9: 5D Mark IV, 200D, 77D, 800D, M6
+	colorDataV9 = colorDataLayout{0x47, []string{wbAsShot, WBAuto, WBMeasured}, nil}
+)
+
+// colorDataLayouts is indexed by the number of values of the tag
+var colorDataLayouts = map[int]colorDataLayout{
+	582: colorDataV1,
+	653: colorDataV2,
+	796: colorDataV3,
+	674: colorDataV4, 692: colorDataV4, 702: colorDataV4, 1227: colorDataV4, 1250: colorDataV4, 1251: colorDataV4, 1337: colorDataV4, 1338: colorDataV4, 1346: colorDataV4,
+	5120: colorDataV5,
+	1273: colorDataV6, 1275: colorDataV6,
+	1312: colorDataV7, 1313: colorDataV7, 1316: colorDataV7, 1506: colorDataV7,
+	1560: colorDataV8, 1592: colorDataV8, 1353: colorDataV8, 1602: colorDataV8,
+	1816: colorDataV9, 1820: colorDataV9, 1824: colorDataV9,
+}
+
+func decodeColorData(values []uint16) (*ColorData, error) {
+	layout, ok := colorDataLayouts[len(values)]
+	if !ok {
+		return nil, fmt.Errorf("unknown ColorData version %d with %d values", int16(values[0]), len(values))
+	}
+	cd := &ColorData{
+		Version: int16(values[0]),
+		Count:   len(values),
+		Presets: make(map[string]WhiteBalance),
+	}
+	for i, name := range layout.records {
+		if name == "" {
+			continue
+		}
+		offset := layout.first + 5*i
+		var wb WhiteBalance
+		copy(wb.Levels[:], values[offset:offset+4])
+		wb.Temperature = values[offset+4]
+		if name == wbAsShot {
+			cd.AsShot = wb
+			for offset += 5; offset+4 <= len(values); offset += 5 {
+				if values[offset+1] == srawGreenLevel {
+					copy(cd.SRAWLevels[:], values[offset:offset+4])
+					break
+				}
+			}
+		} else {
+			cd.Presets[name] = wb
+		}
+	}
+	if offset, ok := layout.whiteLevels[cd.Version]; ok && offset+2 <= len(values) {
+		cd.NormalWhiteLevel = values[offset]
+		cd.SpecularWhiteLevel = values[offset+1]
+	}
+	return cd, nil
+}
+
+// ColorData decodes the white balance records of the Canon ColorData tag.
+func (cf *CR2File) ColorData() (*ColorData, error) {
+	values, err := cf.makerNodeSubIfd.uint16ArrayTag(ExifCanonColorData)
+	if err != nil {
+		return nil, err
+	}
+	cd, err := decodeColorData(values)
+	if err != nil {
+		entry := cf.makerNodeSubIfd.TagsById[ExifCanonColorData]
+		return nil, wrapError(int64(entry.DataOrOffset), GetCanonTagName(ExifCanonColorData), err)
+	}
+	return cd, nil
+}
@@ -0,0 +1,41 @@
+package cr2
+
+import "testing"
+
+func TestColorDataCustomPresets(t *testing.T) {
+	tests := []struct {
+		count   int
+		offsets map[string]int
+	}{
+		// ColorData1
+		{582, map[string]int{wbAsShot: 0x19, WBDaylight: 0x23, WBCustom1: 0x41, WBCustom2: 0x46}},
+		// ColorData3
+		{796, map[string]int{wbAsShot: 0x3f, WBDaylight: 0x4e, WBCustom1: 0x80}},
+		// ColorData7, without custom presets
+		{1316, map[string]int{wbAsShot: 0x3f, WBDaylight: 0x80}},
+	}
+	for _, test := range tests {
+		values := make([]uint16, test.count)
+		want := make(map[string]WhiteBalance)
+		for name, offset := range test.offsets {
+			wb := WhiteBalance{Levels: [4]uint16{uint16(offset), 1024, 1024, uint16(offset + 1)}, Temperature: uint16(offset + 2)}
+			copy(values[offset:], wb.Levels[:])
+			values[offset+4] = wb.Temperature
+			want[name] = wb
+		}
+		cd, err := decodeColorData(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cd.AsShot != want[wbAsShot] {
+			t.Errorf("%d values: as shot %v, want %v", test.count, cd.AsShot, want[wbAsShot])
+		}
+		for _, name := range []string{WBDaylight, WBCustom1, WBCustom2} {
+			wb, ok := cd.Presets[name]
+			wantWB, wantOK := want[name]
+			if ok != wantOK || wb != wantWB {
+				t.Errorf("%d values: %s preset %v (%v), want %v (%v)", test.count, name, wb, ok, wantWB, wantOK)
+			}
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package cr2
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
 	"github.com/lpautet/cr2cv/bufreader"
 	"image"
+	"io"
+	"runtime"
 	"sort"
+	"sync"
 )
 
 type CR2File struct {
@@ -15,194 +17,454 @@ type CR2File struct {
 	ifd0            ImageFileDirectory
 	exifSubIfd      ImageFileDirectory
 	makerNodeSubIfd ImageFileDirectory
+	gpsSubIfd       ImageFileDirectory
+	interopSubIfd   ImageFileDirectory
 	ifd1            ImageFileDirectory
 	ifd2            ImageFileDirectory
 	ifd3            ImageFileDirectory
 	ValuesToExtract []*IFDEntry
 	ValuesByOffset  map[uint32]interface{}
-	Image2, Image3  *image.RGBA64
-	Image0, Image1  image.Image
+	Options         DecodeOptions
+
+	// bytes available in the file for the value at each offset
+	valueSizes map[uint32]int
+	// key in ValuesByOffset of the last value set at a new key, counting
+	// down from 0xFFFFFFFF, 0 before the first one (see valueKey)
+	lastValueKey uint32
+	// keys in ValuesByOffset of the values set or zeroed, the only ones
+	// encoded again by Write
+	edited map[uint32]bool
+
+	reader                              *bufreader.BufferReader
+	image0, image1, image2, raw, active lazyImage
+}
+
+type DecodeOptions struct {
+	// Workers is the number of goroutines decoding the raw image.
+	// Zero means runtime.NumCPU().
+	Workers int
+}
+
+func (o DecodeOptions) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.NumCPU()
+}
+
+type lazyImage struct {
+	once  sync.Once
+	image image.Image
+	err   error
+}
+
+func (l *lazyImage) get(decode func() (image.Image, error)) (image.Image, error) {
+	l.once.Do(func() {
+		l.image, l.err = decode()
+	})
+	return l.image, l.err
+}
+
+// Open parses the header and IFD structure of a CR2 file. Images are only
+// decoded when requested through Thumbnail, Preview, RGBPreview or Raw.
+func Open(r io.ReaderAt) (*CR2File, error) {
+	cf := &CR2File{}
+	cf.Init()
+	if err := cf.ReadFrom(bufreader.NewBufferReader(r, binary.LittleEndian)); err != nil {
+		return nil, err
+	}
+	return cf, nil
 }
 
 func (cf *CR2File) Init() {
 	cf.ValuesByOffset = make(map[uint32]interface{})
+	cf.valueSizes = make(map[uint32]int)
+	cf.edited = make(map[uint32]bool)
 }
 
-func (cf *CR2File) ReadFrom(reader *bufreader.BufferReader) {
-	cf.TiffHeader.readFrom(reader)
-	cf.CR2Header.readFrom(reader)
-
-	if reader.Offset != int64(cf.TiffHeader.TiffOffset) {
-		panic(fmt.Sprintf("Incomplete TIFF tiffHeader read expected offset %d, real %d", cf.TiffHeader.TiffOffset, reader.Offset))
-	}
-
-	cf.ifd0.Init("IFD#0", cf, GetExifTagName)
-	cf.ifd0.readFrom(reader)
-	exifTagOffset := cf.ifd0.TagsById[ExifImageExifTag].Uint32Value()
-	ifd0StripOffset := cf.ifd0.TagsById[ExifImageStripOffset].Uint32Value()
-	ifd0StripBytesCount := cf.ifd0.TagsById[ExifImageStripBytesCount].Uint32Value()
-	cf.extractFields(reader, exifTagOffset)
-	if exifTagOffset > cf.ifd0.NextIFDOffset {
-		panic(fmt.Sprintf("ExifTagOffset > IFD0.NextIFDOffset: %d>%d !", exifTagOffset, cf.ifd0.NextIFDOffset))
-	}
-
-	reader.MoveTo("IFD#0.ExifTagPointer", int64(exifTagOffset))
-	cf.exifSubIfd.Init("ExifSubIfd", cf, GetExifTagName)
-	cf.exifSubIfd.readFrom(reader)
-	makerNoteStartOffset := cf.exifSubIfd.TagsById[ExifPhotoMakerNote].DataOrOffset
-	if cf.exifSubIfd.NextIFDOffset != 0 {
-		panic("Unexpected next IFD Offset in exifSubIfd !")
-	}
-	cf.extractFields(reader, makerNoteStartOffset)
-
-	reader.MoveTo("makerNoteOffset", int64(makerNoteStartOffset))
-	cf.makerNodeSubIfd.Init("MakerNotesIFD", cf, GetCanonTagName)
-	cf.makerNodeSubIfd.readFrom(reader)
-	cf.extractFields(reader, cf.ifd0.NextIFDOffset)
-	if cf.makerNodeSubIfd.NextIFDOffset != 0 {
-		panic("Unexpected next IFD Offset in makerNodeSubIfd !")
-	}
-
-	reader.MoveTo("IFD#0.NextIFDOffset", int64(cf.ifd0.NextIFDOffset))
-	cf.ifd1.Init("IFD#1", cf, GetExifTagName)
-	cf.ifd1.readFrom(reader)
-	cf.extractFields(reader, cf.ifd1.NextIFDOffset)
-	ifd1ThumbnailOffset := cf.ifd1.TagsById[ExifImageThumbnailOffset].Uint32Value()
-	idf1ThumbnailLength := cf.ifd1.TagsById[ExifImageThumbnailLength].Uint32Value()
-
-	reader.MoveTo("IFD#1.NextIFDOffset", int64(cf.ifd1.NextIFDOffset))
-	cf.ifd2.Init("IFD#2", cf, GetExifTagName)
-	cf.ifd2.readFrom(reader)
-	ifd2ImageWidth := cf.ifd2.TagsById[ExifImageWidth].Uint16Value()
-	ifd2ImageHeight := cf.ifd2.TagsById[ExifImageHeight].Uint16Value()
-	ifd2StripOffset := cf.ifd2.TagsById[ExifImageStripOffset].Uint32Value()
-	cf.extractFields(reader, cf.ifd2.NextIFDOffset)
-
-	reader.MoveTo("IFD#2.NextIFDOffset", int64(cf.ifd2.NextIFDOffset))
-	if int64(cf.CR2Header.RawIfdOffset) != reader.Offset {
-		panic("IDF#3 offset not equals to CR2 Header RawIdOffset !")
-	}
-	cf.ifd3.Init("IFD#3", cf, GetExifTagName)
-	cf.ifd3.readFrom(reader)
-	ifd3ImageWidth := cf.ifd3.TagsById[ExifImageWidth].Uint16Value()
-	ifd3ImageHeight := cf.ifd3.TagsById[ExifImageHeight].Uint16Value()
-	ifd3StripOffset := cf.ifd3.TagsById[ExifImageStripOffset].Uint32Value()
-	ifd3StripBytesCount := cf.ifd3.TagsById[ExifImageStripBytesCount].Uint32Value()
-	cf.extractFields(reader, ifd1ThumbnailOffset)
-	uint16buffer := cf.ifd3.TagsById[ExifImageCR2Slice].Uint16ArrayValue(cf)
-	ifd3CR2Slice := Slice{SliceCount: uint16buffer[0], SliceSize: uint16buffer[1], LastSliceSize: uint16buffer[2]}
-	if cf.ifd3.NextIFDOffset != 0 {
-		panic(fmt.Sprintf("Unexpected IFD after IFD#3 !"))
-	}
-
-	cf.ifd0.dumpTags(cf)
-	cf.exifSubIfd.dumpTags(cf)
-	cf.makerNodeSubIfd.dumpTags(cf)
-	cf.ifd1.dumpTags(cf)
-	cf.ifd2.dumpTags(cf)
-	cf.ifd3.dumpTags(cf)
-
-	reader.MoveTo("IFD#1.ThumbnailOffset", int64(ifd1ThumbnailOffset))
-	cf.Image1 = readJpegImage(reader, idf1ThumbnailLength)
-
-	reader.MoveTo("IFD#0.StripOffsets", int64(ifd0StripOffset))
-	cf.Image0 = readJpegImage(reader, ifd0StripBytesCount)
-
-	reader.MoveTo("IFD#2.StripOffsets", int64(ifd2StripOffset))
-	cf.Image2 = readRGBAImage(reader, ifd2ImageWidth, ifd2ImageHeight)
-
-	reader.MoveTo("IFD#3.StripOffsets", int64(ifd3StripOffset))
-	rawDataBuffer := make([]byte, ifd3StripBytesCount)
-	fmt.Printf("Reading %d bytes of RAW image...", ifd3StripBytesCount)
-	reader.ReadInto(int64(ifd3StripBytesCount), &rawDataBuffer)
-	fmt.Printf("done\n")
-	rawReader := bufreader.BufferReader{
-		Reader:    bytes.NewReader(rawDataBuffer),
-		ByteOrder: binary.BigEndian,
-		Offset:    0,
-	}
-	cf.Image3 = readRawImage(&rawReader, ifd3ImageWidth, ifd3ImageHeight, ifd3CR2Slice)
+func (cf *CR2File) ReadFrom(reader *bufreader.BufferReader) error {
+	if err := reader.MoveTo("TIFF header", 0); err != nil {
+		return wrapError(reader.Offset, "TIFF header", err)
+	}
+	if err := cf.TiffHeader.readFrom(reader); err != nil {
+		return err
+	}
+	if err := cf.CR2Header.readFrom(reader); err != nil {
+		return err
+	}
+
+	if err := cf.readIFD(reader, &cf.ifd0, "IFD#0", cf.TiffHeader.TiffOffset, GetExifTagName); err != nil {
+		return err
+	}
+	exifTagOffset, err := cf.ifd0.uintTag(ExifImageExifTag)
+	if err != nil {
+		return err
+	}
+
+	if err := cf.readIFD(reader, &cf.exifSubIfd, "ExifSubIfd", exifTagOffset, GetExifTagName); err != nil {
+		return err
+	}
+	if interopTag := cf.exifSubIfd.TagsById[ExifPhotoInteroperabilityTag]; interopTag != nil {
+		interopTagOffset, err := cf.exifSubIfd.uintTag(ExifPhotoInteroperabilityTag)
+		if err != nil {
+			return err
+		}
+		if err := cf.readIFD(reader, &cf.interopSubIfd, "InteropSubIfd", interopTagOffset, GetInteropTagName); err != nil {
+			return err
+		}
+	}
+	makerNote := cf.exifSubIfd.TagsById[ExifPhotoMakerNote]
+	if makerNote == nil {
+		return formatErrorf(cf.exifSubIfd.Offset, cf.exifSubIfd.Name, "missing tag %s", GetExifTagName(ExifPhotoMakerNote))
+	}
+	if err := cf.readIFD(reader, &cf.makerNodeSubIfd, "MakerNotesIFD", makerNote.DataOrOffset, GetCanonTagName); err != nil {
+		return err
+	}
+
+	if gpsTag := cf.ifd0.TagsById[ExifImageGPSTag]; gpsTag != nil {
+		gpsTagOffset, err := cf.ifd0.uintTag(ExifImageGPSTag)
+		if err != nil {
+			return err
+		}
+		if err := cf.readIFD(reader, &cf.gpsSubIfd, "GPSSubIfd", gpsTagOffset, GetGPSTagName); err != nil {
+			return err
+		}
+	}
+
+	if err := cf.readIFD(reader, &cf.ifd1, "IFD#1", cf.ifd0.NextIFDOffset, GetExifTagName); err != nil {
+		return err
+	}
+	if err := cf.readIFD(reader, &cf.ifd2, "IFD#2", cf.ifd1.NextIFDOffset, GetExifTagName); err != nil {
+		return err
+	}
+	if err := cf.readIFD(reader, &cf.ifd3, "IFD#3", cf.CR2Header.RawIfdOffset, GetExifTagName); err != nil {
+		return err
+	}
+
+	cf.reader = reader
+	return nil
+}
+
+func (cf *CR2File) DumpTags() {
+	for _, ifd := range cf.ifds() {
+		ifd.dumpTags()
+	}
+	if cs, err := cf.CameraSettings(); err == nil {
+		fmt.Printf("CameraSettings: %+v\n", *cs)
+	}
+	if si, err := cf.ShotInfo(); err == nil {
+		fmt.Printf("ShotInfo: %+v\n", *si)
+	}
+	if af, err := cf.AFInfo(); err == nil {
+		fmt.Printf("AFInfo: %v, %d points, in focus:", af.AreaMode, len(af.Points))
+		for _, p := range af.InFocus() {
+			fmt.Printf(" %v", p.Rect)
+		}
+		fmt.Printf("\n")
+	}
+	fmt.Printf("Lens: %+v\n", *cf.Lens())
+}
+
+// ifds returns the IFDs of the file, in the order they are read.
+func (cf *CR2File) ifds() []*ImageFileDirectory {
+	ifds := []*ImageFileDirectory{&cf.ifd0, &cf.exifSubIfd}
+	if cf.interopSubIfd.ParentFile != nil {
+		ifds = append(ifds, &cf.interopSubIfd)
+	}
+	ifds = append(ifds, &cf.makerNodeSubIfd)
+	if cf.gpsSubIfd.ParentFile != nil {
+		ifds = append(ifds, &cf.gpsSubIfd)
+	}
+	return append(ifds, &cf.ifd1, &cf.ifd2, &cf.ifd3)
+}
+
+// IFD0 returns the first IFD, holding the main TIFF tags.
+func (cf *CR2File) IFD0() *ImageFileDirectory {
+	return &cf.ifd0
+}
+
+// ExifIFD returns the EXIF sub-IFD.
+func (cf *CR2File) ExifIFD() *ImageFileDirectory {
+	return &cf.exifSubIfd
+}
+
+// InteropIFD returns the Interoperability IFD, nil when the file has none.
+func (cf *CR2File) InteropIFD() *ImageFileDirectory {
+	if cf.interopSubIfd.ParentFile == nil {
+		return nil
+	}
+	return &cf.interopSubIfd
+}
+
+// MakerNoteIFD returns the Canon maker note IFD. Its values can be changed
+// but it cannot get more entries than in the file.
+func (cf *CR2File) MakerNoteIFD() *ImageFileDirectory {
+	return &cf.makerNodeSubIfd
+}
+
+// GPSIFD returns the GPS sub-IFD, adding an empty one to the file when it
+// has none.
+func (cf *CR2File) GPSIFD() *ImageFileDirectory {
+	if cf.gpsSubIfd.ParentFile == nil {
+		cf.gpsSubIfd.Init("GPSSubIfd", cf, GetGPSTagName)
+	}
+	if cf.ifd0.TagsById[ExifImageGPSTag] == nil {
+		// the offset is set when writing
+		cf.ifd0.SetUint32(ExifImageGPSTag, 0)
+	}
+	return &cf.gpsSubIfd
+}
+
+// Thumbnail returns the small JPEG image referenced by IFD#1.
+func (cf *CR2File) Thumbnail() (image.Image, error) {
+	return cf.image1.get(func() (image.Image, error) {
+		ifd1ThumbnailOffset, err := cf.ifd1.uintTag(ExifImageThumbnailOffset)
+		if err != nil {
+			return nil, err
+		}
+		idf1ThumbnailLength, err := cf.ifd1.uintTag(ExifImageThumbnailLength)
+		if err != nil {
+			return nil, err
+		}
+		img, err := readJpegImage(cf.reader.At(int64(ifd1ThumbnailOffset)), idf1ThumbnailLength)
+		if err != nil {
+			return nil, wrapError(int64(ifd1ThumbnailOffset), "Image#1", err)
+		}
+		return img, nil
+	})
+}
+
+// Preview returns the full size JPEG image referenced by IFD#0.
+func (cf *CR2File) Preview() (image.Image, error) {
+	return cf.image0.get(func() (image.Image, error) {
+		ifd0StripOffset, err := cf.ifd0.uintTag(ExifImageStripOffset)
+		if err != nil {
+			return nil, err
+		}
+		ifd0StripBytesCount, err := cf.ifd0.uintTag(ExifImageStripBytesCount)
+		if err != nil {
+			return nil, err
+		}
+		img, err := readJpegImage(cf.reader.At(int64(ifd0StripOffset)), ifd0StripBytesCount)
+		if err != nil {
+			return nil, wrapError(int64(ifd0StripOffset), "Image#0", err)
+		}
+		return img, nil
+	})
+}
+
+// RGBPreview returns the small uncompressed RGB image referenced by IFD#2.
+func (cf *CR2File) RGBPreview() (*image.RGBA64, error) {
+	img, err := cf.image2.get(func() (image.Image, error) {
+		ifd2ImageWidth, err := cf.ifd2.uintTag(ExifImageWidth)
+		if err != nil {
+			return nil, err
+		}
+		ifd2ImageHeight, err := cf.ifd2.uintTag(ExifImageHeight)
+		if err != nil {
+			return nil, err
+		}
+		ifd2StripOffset, err := cf.ifd2.uintTag(ExifImageStripOffset)
+		if err != nil {
+			return nil, err
+		}
+		img, err := readRGBAImage(cf.reader.At(int64(ifd2StripOffset)), uint16(ifd2ImageWidth), uint16(ifd2ImageHeight))
+		if err != nil {
+			return nil, wrapError(int64(ifd2StripOffset), "Image#2", err)
+		}
+		return img, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return img.(*image.RGBA64), nil
+}
+
+// FullRaw returns the sensor data stored as lossless JPEG in IFD#3, masked
+// borders included and black level not subtracted.
+func (cf *CR2File) FullRaw() (*RawImage, error) {
+	img, err := cf.decodeRaw()
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := img.(*RawImage)
+	if !ok {
+		return nil, ErrSRaw
+	}
+	return raw, nil
+}
+
+// SRaw returns the image of an sRAW or mRAW file, as linear camera RGB.
+func (cf *CR2File) SRaw() (*image.RGBA64, error) {
+	img, err := cf.decodeRaw()
+	if err != nil {
+		return nil, err
+	}
+	rgb, ok := img.(*image.RGBA64)
+	if !ok {
+		return nil, ErrNotSRaw
+	}
+	return rgb, nil
+}
+
+// IsSRaw reports whether IFD#3 holds an sRAW or mRAW image rather than CFA
+// data. The image is decoded to find out.
+func (cf *CR2File) IsSRaw() (bool, error) {
+	img, err := cf.decodeRaw()
+	if err != nil {
+		return false, err
+	}
+	_, ok := img.(*image.RGBA64)
+	return ok, nil
+}
+
+func (cf *CR2File) decodeRaw() (image.Image, error) {
+	return cf.raw.get(func() (image.Image, error) {
+		ifd3StripOffset, err := cf.ifd3.uintTag(ExifImageStripOffset)
+		if err != nil {
+			return nil, err
+		}
+		ifd3StripBytesCount, err := cf.ifd3.uintTag(ExifImageStripBytesCount)
+		if err != nil {
+			return nil, err
+		}
+		sliceEntry := cf.ifd3.TagsById[ExifImageCR2Slice]
+		if sliceEntry == nil || sliceEntry.TagType != TagTypeUint16 || sliceEntry.NumberOfValues != 3 {
+			return nil, formatErrorf(cf.ifd3.Offset, cf.ifd3.Name, "missing or invalid tag %s", GetExifTagName(ExifImageCR2Slice))
+		}
+		uint16buffer := sliceEntry.Uint16ArrayValue(cf)
+		if uint16buffer == nil {
+			return nil, formatErrorf(int64(sliceEntry.DataOrOffset), cf.ifd3.Name, "value of %s not found", GetExifTagName(ExifImageCR2Slice))
+		}
+		ifd3CR2Slice := Slice{SliceCount: uint16buffer[0], SliceSize: uint16buffer[1], LastSliceSize: uint16buffer[2]}
+
+		rawDataBuffer, err := cf.reader.At(int64(ifd3StripOffset)).ReadBuffer(int64(ifd3StripBytesCount))
+		if err != nil {
+			return nil, wrapError(int64(ifd3StripOffset), "Image#3", err)
+		}
+		raw, lumaSamples, err := readRawImage(rawDataBuffer, int64(ifd3StripOffset), ifd3CR2Slice, cf.Options.workers())
+		if err != nil {
+			return nil, err
+		}
+		if lumaSamples == 0 {
+			return raw, nil
+		}
+		return srawToRGB(raw, lumaSamples, cf.srawParams(), cf.Options.workers()), nil
+	})
+}
+
+func (cf *CR2File) readIFD(reader *bufreader.BufferReader, ifd *ImageFileDirectory, name string, offset uint32, resolver TagNameResolver) error {
+	if err := reader.MoveTo(name, int64(offset)); err != nil {
+		return wrapError(reader.Offset, name, err)
+	}
+	ifd.Init(name, cf, resolver)
+	if err := ifd.readFrom(reader); err != nil {
+		return err
+	}
+	return cf.extractFields(reader)
 }
 
 func (cf *CR2File) AddValueToExtract(entry *IFDEntry) {
+	if size := int(entry.NumberOfValues) * tagTypeSize(entry.TagType); size > cf.valueSizes[entry.DataOrOffset] {
+		cf.valueSizes[entry.DataOrOffset] = size
+	}
 	if cf.ValuesByOffset[entry.DataOrOffset] != nil {
 		return
 	}
 	cf.ValuesToExtract = append(cf.ValuesToExtract, entry)
 }
 
-func (cf *CR2File) AddDataAtOffset(offset uint32, val interface{}) {
+func (cf *CR2File) AddDataAtOffset(offset uint32, val interface{}) error {
 	value := cf.ValuesByOffset[offset]
-	if value == nil {
-		cf.ValuesByOffset[offset] = val
-	} else {
-		panic(fmt.Sprintf("Already got value for offset %x", offset))
+	if value != nil {
+		return formatErrorf(int64(offset), "values", "already got value for offset %x", offset)
 	}
+	cf.ValuesByOffset[offset] = val
+	return nil
 }
 
-func (cf *CR2File) extractFields(reader *bufreader.BufferReader, limit uint32) {
+func (cf *CR2File) extractFields(reader *bufreader.BufferReader) error {
 
 	valuesToExtract := cf.ValuesToExtract
 	cf.ValuesToExtract = make([]*IFDEntry, 0)
 	sort.Sort(ByOffset{valuesToExtract})
 
-	for i, entry := range valuesToExtract {
+	for _, entry := range valuesToExtract {
 
 		name := GetExifTagName(entry.TagID)
 
-		if limit != 0 && entry.DataOrOffset >= limit {
-			//fmt.Printf("Requested offset for %s is past limit ! %d>=%d, stopping extraction there\n", name, entry.DataOrOffset, limit)
-			cf.ValuesToExtract = valuesToExtract[i:]
-			break
-		}
-
-		if reader.Offset > int64(entry.DataOrOffset) {
-			//fmt.Printf("Field %s points to an offset BEFORE CURRENT, skipping field %d<%d\n", name, entry.DataOrOffset, reader.Offset)
+		if cf.ValuesByOffset[entry.DataOrOffset] != nil {
 			continue
 		}
 
-		reader.MoveTo(name, int64(entry.DataOrOffset))
+		if err := reader.MoveTo(name, int64(entry.DataOrOffset)); err != nil {
+			return wrapError(reader.Offset, name, err)
+		}
 		//fmt.Printf("@%d %d ", entry.DataOrOffset, reader.Offset)
+		size := int64(entry.NumberOfValues) * int64(tagTypeSize(entry.TagType))
+		if err := reader.CheckAvailable(int64(entry.DataOrOffset), size); err != nil {
+			return formatErrorf(int64(entry.DataOrOffset), name, "value of %d bytes past the end of the file", size)
+		}
 
+		var val interface{}
+		var err error
 		switch entry.TagType {
 		case TagTypeString:
-			buffer := reader.ReadBuffer(int64(entry.NumberOfValues))
-			val := string(buffer[:len(buffer)-1])
-			cf.AddDataAtOffset(entry.DataOrOffset, val)
+			var buffer []byte
+			buffer, err = reader.ReadBuffer(int64(entry.NumberOfValues))
+			if err == nil && len(buffer) > 0 {
+				// NUL included, so that the value is written back as read
+				val = string(buffer)
+			}
 			//fmt.Printf("%s=%s\n", name, val)
 		case TagTypeUint16:
-			val := make([]uint16, entry.NumberOfValues)
-			reader.ReadInto(int64(2*entry.NumberOfValues), &val)
-			cf.AddDataAtOffset(entry.DataOrOffset, val)
+			values := make([]uint16, entry.NumberOfValues)
+			err = reader.ReadInto(2*int64(entry.NumberOfValues), &values)
+			val = values
 			//fmt.Printf("%s=%v\n", name, val)
 		case TagTypeUint32:
-			val := make([]uint32, entry.NumberOfValues)
-			reader.ReadInto(int64(4*entry.NumberOfValues), &val)
-			cf.AddDataAtOffset(entry.DataOrOffset, val)
+			values := make([]uint32, entry.NumberOfValues)
+			err = reader.ReadInto(4*int64(entry.NumberOfValues), &values)
+			val = values
 			//fmt.Printf("%s=%v\n", name, val)
 		case TagTypeUrational:
-			var val Rational
-			reader.ReadInto(8, &val)
-			cf.AddDataAtOffset(entry.DataOrOffset, val)
+			rationals := make([]Rational, entry.NumberOfValues)
+			err = reader.ReadInto(8*int64(entry.NumberOfValues), &rationals)
+			val = rationals
+			if len(rationals) == 1 {
+				val = rationals[0]
+			}
 			//fmt.Printf("%s=%d/%d\n", name, val.Numerator, val.Denominator)
 		case TagTypeByteSequence:
-			val := reader.ReadBuffer(int64(entry.NumberOfValues))
-			cf.AddDataAtOffset(entry.DataOrOffset, val)
+			val, err = reader.ReadBuffer(int64(entry.NumberOfValues))
 			//fmt.Printf("%s=%v\n", name, val)
 		case TagTypeUbyte:
-			val := make([]uint8, entry.NumberOfValues)
-			reader.ReadInto(int64(entry.NumberOfValues), &val)
-			cf.AddDataAtOffset(entry.DataOrOffset, val)
+			values := make([]uint8, entry.NumberOfValues)
+			err = reader.ReadInto(int64(entry.NumberOfValues), &values)
+			val = values
 			//fmt.Printf("%s=%v\n", name, val)
 		case TagTypeRational:
-			var val SRational
-			reader.ReadInto(8, &val)
-			cf.AddDataAtOffset(entry.DataOrOffset, val)
+			rationals := make([]SRational, entry.NumberOfValues)
+			err = reader.ReadInto(8*int64(entry.NumberOfValues), &rationals)
+			val = rationals
+			if len(rationals) == 1 {
+				val = rationals[0]
+			}
 			//fmt.Printf("%s=%d/%d\n", name, val.Numerator, val.Denominator)
 		default:
-			fmt.Printf("TagType cannot be extracted for %s: %d\n", name, entry.TagType)
+			continue
+		}
+		if err != nil {
+			return wrapError(reader.Offset, name, err)
+		}
+		if val == nil {
+			continue
+		}
+		if err := cf.AddDataAtOffset(entry.DataOrOffset, val); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
 type CR2Header struct {
@@ -212,14 +474,17 @@ type CR2Header struct {
 	RawIfdOffset uint32
 }
 
-func (h *CR2Header) readFrom(reader *bufreader.BufferReader) {
-	reader.ReadInto(8, h)
+func (h *CR2Header) readFrom(reader *bufreader.BufferReader) error {
+	if err := reader.ReadInto(8, h); err != nil {
+		return wrapError(reader.Offset, "CR2 header", err)
+	}
 	if h.Cr2Magic != 0x5243 {
-		panic(fmt.Sprintf("Invalid CR magic: %x\n", h.Cr2Magic))
+		return ErrBadMagic
 	}
 	if (h.Cr2Major != 2) || (h.Cr2Minor != 0) {
-		panic(fmt.Sprintf("Unsuported CR2 version\n"))
+		return ErrUnsupportedVersion
 	}
+	return nil
 }
 
 type Rational struct {
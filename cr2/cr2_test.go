@@ -0,0 +1,70 @@
+package cr2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// readFixture returns the test file built by buildTestFile.
+func readFixture(t testing.TB) []byte {
+	return buildTestFile(t)
+}
+
+func openBytes(t testing.TB, data []byte) *CR2File {
+	cf, err := Open(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cf
+}
+
+// entryOffset returns the position in data of the entry of a tag in IFD#0.
+func entryOffset(t testing.TB, data []byte, tagID uint16) int {
+	ifd := int(binary.LittleEndian.Uint32(data[4:]))
+	n := int(binary.LittleEndian.Uint16(data[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + 12*i
+		if binary.LittleEndian.Uint16(data[entry:]) == tagID {
+			return entry
+		}
+	}
+	t.Fatalf("tag 0x%04x not found in IFD#0", tagID)
+	return 0
+}
+
+func TestOpenRejectsValuesPastEnd(t *testing.T) {
+	for _, count := range []uint32{0x40000000, 0xffffffff, uint32(len(readFixture(t)))} {
+		data := readFixture(t)
+		entry := entryOffset(t, data, ExifImageModel)
+		binary.LittleEndian.PutUint32(data[entry+4:], count)
+		_, err := Open(bytes.NewReader(data))
+		if _, ok := err.(*FormatError); !ok {
+			t.Errorf("count %d: got error %v, want a *FormatError", count, err)
+		}
+	}
+}
+
+func TestOpenTruncated(t *testing.T) {
+	data := readFixture(t)
+	for _, size := range []int{0, 8, 20, 200, 400} {
+		if _, err := Open(bytes.NewReader(data[:size])); err == nil {
+			t.Errorf("size %d: no error", size)
+		}
+	}
+}
+
+// The tags of the EXIF sub-IFD are named in the Photo group, those of IFD#0
+// in the Image one.
+func TestExifTagGroups(t *testing.T) {
+	cf := openBytes(t, readFixture(t))
+	groups := map[string]*ImageFileDirectory{"Exif.Image.": cf.IFD0(), "Exif.Photo.": cf.ExifIFD()}
+	for group, ifd := range groups {
+		for _, entry := range ifd.Entries {
+			if name := GetExifTagName(entry.TagID); !strings.HasPrefix(name, group) {
+				t.Errorf("%s tag %#04x named %s, want it in %s", ifd.Name, entry.TagID, name, group)
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package cr2
+
+import (
+	"fmt"
+	"github.com/lpautet/cr2cv/colorspace"
+	"github.com/lpautet/cr2cv/demosaic"
+	"github.com/lpautet/cr2cv/internal/imageutil"
+	"image"
+)
+
+type WhiteBalanceMode int
+
+const (
+	// WhiteBalanceAsShot uses the levels recorded by the camera
+	WhiteBalanceAsShot WhiteBalanceMode = iota
+	WhiteBalanceDaylight
+	// WhiteBalanceCustom uses DevelopOptions.Multipliers
+	WhiteBalanceCustom
+	// WhiteBalanceNone leaves the raw channels unscaled
+	WhiteBalanceNone
+)
+
+func (m WhiteBalanceMode) String() string {
+	switch m {
+	case WhiteBalanceAsShot:
+		return "asshot"
+	case WhiteBalanceDaylight:
+		return "daylight"
+	case WhiteBalanceCustom:
+		return "custom"
+	case WhiteBalanceNone:
+		return "none"
+	}
+	return fmt.Sprintf("WhiteBalanceMode(%d)", int(m))
+}
+
+func ParseWhiteBalanceMode(name string) (WhiteBalanceMode, error) {
+	for _, m := range []WhiteBalanceMode{WhiteBalanceAsShot, WhiteBalanceDaylight, WhiteBalanceCustom, WhiteBalanceNone} {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("cr2: unknown white balance %q", name)
+}
+
+// DevelopOptions selects how the raw image is turned into an RGB image.
+type DevelopOptions struct {
+	Demosaic     demosaic.Algorithm
+	WhiteBalance WhiteBalanceMode
+	// Multipliers are the red, green and blue multipliers used with
+	// WhiteBalanceCustom
+	Multipliers [3]float32
+	Highlights  HighlightMode
+	// ColorSpace is the colour space of the developed image
+	ColorSpace colorspace.Space
+	// Linear skips the transfer function of ColorSpace in Develop
+	Linear bool
+}
+
+// WhiteBalanceMultipliers returns the red, green and blue multipliers applied
+// to the raw data for the given mode.
+func (cf *CR2File) WhiteBalanceMultipliers(opts DevelopOptions) ([3]float32, error) {
+	switch opts.WhiteBalance {
+	case WhiteBalanceNone:
+		return [3]float32{1, 1, 1}, nil
+	case WhiteBalanceCustom:
+		for _, m := range opts.Multipliers {
+			if m <= 0 {
+				return [3]float32{}, fmt.Errorf("cr2: invalid white balance multipliers %v", opts.Multipliers)
+			}
+		}
+		return opts.Multipliers, nil
+	case WhiteBalanceAsShot, WhiteBalanceDaylight:
+	default:
+		return [3]float32{}, fmt.Errorf("cr2: unknown white balance %v", opts.WhiteBalance)
+	}
+	cd, err := cf.ColorData()
+	if err != nil {
+		return [3]float32{}, err
+	}
+	if opts.WhiteBalance == WhiteBalanceAsShot {
+		return cd.AsShot.Multipliers(), nil
+	}
+	daylight, ok := cd.Presets[WBDaylight]
+	if !ok {
+		return [3]float32{}, fmt.Errorf("cr2: no daylight white balance in ColorData version %d", cd.Version)
+	}
+	return daylight.Multipliers(), nil
+}
+
+// DevelopRGB decodes the raw image, white balances it, interpolates it into a
+// full colour image, handles its highlights and converts it to linear values
+// of opts.ColorSpace.
+func (cf *CR2File) DevelopRGB(opts DevelopOptions) (*demosaic.RGB, error) {
+	sraw, err := cf.IsSRaw()
+	if err != nil {
+		return nil, err
+	}
+	multipliers, err := cf.WhiteBalanceMultipliers(opts)
+	if err != nil {
+		return nil, err
+	}
+	matrix, err := cf.CameraToSpace(opts.ColorSpace)
+	if err != nil {
+		return nil, err
+	}
+	var rgb *demosaic.RGB
+	if sraw {
+		if rgb, multipliers, err = cf.srawRGB(multipliers); err != nil {
+			return nil, err
+		}
+	} else {
+		raw, err := cf.Raw()
+		if err != nil {
+			return nil, err
+		}
+		rgb, err = demosaic.Demosaic(raw.ScaledMosaic(multipliers), demosaic.Options{
+			Algorithm: opts.Demosaic,
+			Workers:   cf.Options.workers(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	// samples at the white level scale to 1, before white balance
+	var clip [3]float32
+	for c, m := range multipliers {
+		clip[c] = m * 0.9999
+	}
+	if err := recoverHighlights(rgb, clip, opts.Highlights, cf.Options.workers()); err != nil {
+		return nil, err
+	}
+	colorspace.Convert(rgb, matrix, cf.Options.workers())
+	return rgb, nil
+}
+
+// srawRGB returns the sRAW image scaled by multipliers. The camera white
+// balances sRAW data with the as shot levels, so only the ratio of multipliers
+// to them is applied, and returned.
+func (cf *CR2File) srawRGB(multipliers [3]float32) (*demosaic.RGB, [3]float32, error) {
+	img, err := cf.SRaw()
+	if err != nil {
+		return nil, multipliers, err
+	}
+	asShot := [3]float32{1, 1, 1}
+	if cd, err := cf.ColorData(); err == nil {
+		asShot = cd.AsShot.Multipliers()
+	}
+	for c := range multipliers {
+		multipliers[c] /= asShot[c]
+	}
+	rgb := demosaic.NewRGB(img.Rect)
+	width := img.Rect.Dx()
+	imageutil.ParallelFor(img.Rect.Dy(), cf.Options.workers(), func(y int) {
+		src := img.Pix[y*img.Stride:]
+		dst := rgb.Pix[y*rgb.Stride:]
+		for x := 0; x < width; x++ {
+			for c := 0; c < 3; c++ {
+				v := uint16(src[8*x+2*c])<<8 | uint16(src[8*x+2*c+1])
+				dst[3*x+c] = float32(v) / 0xffff * multipliers[c]
+			}
+		}
+	})
+	return rgb, multipliers, nil
+}
+
+// Develop returns the developed image with 16 bits per channel, display
+// referred unless opts.Linear is set.
+func (cf *CR2File) Develop(opts DevelopOptions) (*image.RGBA64, error) {
+	rgb, err := cf.DevelopRGB(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Linear {
+		return rgb.RGBA64(), nil
+	}
+	return opts.ColorSpace.EncodeImage(rgb, cf.Options.workers()), nil
+}
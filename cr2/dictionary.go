@@ -0,0 +1,131 @@
+package cr2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// TagInfo describes a tag of the EXIF 2.32 and TIFF 6.0 specifications.
+type TagInfo struct {
+	Name  string
+	Types []uint16
+	// Count is the number of values, 0 for any
+	Count uint32
+	// Values gives the meaning of enumerated values, by formatted value
+	Values map[string]string
+}
+
+// LookupTag returns the description of a tag from its name, such as
+// Exif.Photo.MeteringMode, or nil for an unknown tag.
+func LookupTag(name string) *TagInfo {
+	return tagInfos[name]
+}
+
+// check returns what does not match the specification in an entry, or "".
+func (info *TagInfo) check(entry *IFDEntry) string {
+	known := false
+	for _, tagType := range info.Types {
+		known = known || tagType == entry.TagType
+	}
+	if !known {
+		return fmt.Sprintf("unexpected type %d", entry.TagType)
+	}
+	if info.Count != 0 && entry.TagType != TagTypeString && entry.NumberOfValues != info.Count {
+		return fmt.Sprintf("unexpected count %d", entry.NumberOfValues)
+	}
+	return ""
+}
+
+// maximum number of values formatted for an array
+const formattedValues = 16
+
+// formatValue returns the value of an entry as text, followed by the meaning
+// of enumerated values.
+func (ifd *ImageFileDirectory) formatValue(entry *IFDEntry) string {
+	file := ifd.ParentFile
+	var text string
+	switch entry.TagType {
+	case TagTypeString:
+		text = strings.TrimRight(entry.StringValue(file), "\x00")
+	case TagTypeUbyte, TagTypeByteSequence:
+		var data []byte
+		if entry.outOfLine() {
+			data, _ = file.ValuesByOffset[entry.DataOrOffset].([]byte)
+		} else {
+			data = entry.inlineBytes()
+		}
+		if entry.TagType == TagTypeByteSequence && isText(data) {
+			text = strings.Join(strings.FieldsFunc(string(data), func(r rune) bool { return r == 0 }), " ")
+			break
+		}
+		text = formatList(len(data), func(i int) interface{} { return data[i] })
+	case TagTypeUint16:
+		values, _ := file.ValuesByOffset[entry.DataOrOffset].([]uint16)
+		if !entry.outOfLine() {
+			data := entry.inlineBytes()
+			values = make([]uint16, len(data)/2)
+			for i := range values {
+				values[i] = binary.LittleEndian.Uint16(data[2*i:])
+			}
+		}
+		text = formatList(len(values), func(i int) interface{} { return values[i] })
+	case TagTypeUint32:
+		values, _ := file.ValuesByOffset[entry.DataOrOffset].([]uint32)
+		if !entry.outOfLine() {
+			values = []uint32{entry.DataOrOffset}
+		}
+		text = formatList(len(values), func(i int) interface{} { return values[i] })
+	case TagTypeUrational:
+		values := ifd.rationalsTag(entry.TagID)
+		text = formatList(len(values), func(i int) interface{} { return values[i] })
+	default:
+		text = fmt.Sprint(entry.Value(file))
+	}
+
+	if info := LookupTag(ifd.resolver(entry.TagID)); info != nil {
+		if meaning, ok := info.Values[text]; ok {
+			text += " (" + meaning + ")"
+		}
+		if problem := info.check(entry); problem != "" {
+			text += " [" + problem + "]"
+		}
+	}
+	return text
+}
+
+// inlineBytes returns the bytes of a value stored in the entry.
+func (e *IFDEntry) inlineBytes() []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, e.DataOrOffset)
+	return data[:int(e.NumberOfValues)*tagTypeSize(e.TagType)]
+}
+
+// isText reports whether data is printable ASCII, with NUL padding.
+func isText(data []byte) bool {
+	printable := 0
+	for _, b := range data {
+		if b >= 0x20 && b < 0x7f {
+			printable++
+		} else if b != 0 {
+			return false
+		}
+	}
+	return printable > 0
+}
+
+// formatList formats a single value as is and arrays in brackets, truncated
+// to formattedValues values.
+func formatList(n int, value func(i int) interface{}) string {
+	if n == 1 {
+		return fmt.Sprint(value(0))
+	}
+	items := make([]string, 0, formattedValues)
+	for i := 0; i < n && i < formattedValues; i++ {
+		items = append(items, fmt.Sprint(value(i)))
+	}
+	if n > formattedValues {
+		return fmt.Sprintf("[%s ...] (%d values)", strings.Join(items, " "), n)
+	}
+	return "[" + strings.Join(items, " ") + "]"
+}
@@ -0,0 +1,176 @@
+package cr2
+
+import (
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// SetString sets an ASCII tag, NUL terminated unless value already is.
+func (ifd *ImageFileDirectory) SetString(tagID uint16, value string) {
+	ifd.setTag(tagID, TagTypeString, value)
+}
+
+// SetUint8 sets a BYTE tag.
+func (ifd *ImageFileDirectory) SetUint8(tagID uint16, values ...uint8) {
+	ifd.setTag(tagID, TagTypeUbyte, values)
+}
+
+// SetByteSequence sets an UNDEFINED tag.
+func (ifd *ImageFileDirectory) SetByteSequence(tagID uint16, value []byte) {
+	ifd.setTag(tagID, TagTypeByteSequence, value)
+}
+
+// SetUint16 sets a SHORT tag.
+func (ifd *ImageFileDirectory) SetUint16(tagID uint16, values ...uint16) {
+	ifd.setTag(tagID, TagTypeUint16, values)
+}
+
+// SetUint32 sets a LONG tag.
+func (ifd *ImageFileDirectory) SetUint32(tagID uint16, values ...uint32) {
+	ifd.setTag(tagID, TagTypeUint32, values)
+}
+
+// SetRational sets a RATIONAL tag.
+func (ifd *ImageFileDirectory) SetRational(tagID uint16, values ...Rational) {
+	if len(values) == 1 {
+		ifd.setTag(tagID, TagTypeUrational, values[0])
+		return
+	}
+	ifd.setTag(tagID, TagTypeUrational, values)
+}
+
+// SetSRational sets an SRATIONAL tag.
+func (ifd *ImageFileDirectory) SetSRational(tagID uint16, values ...SRational) {
+	if len(values) == 1 {
+		ifd.setTag(tagID, TagTypeRational, values[0])
+		return
+	}
+	ifd.setTag(tagID, TagTypeRational, values)
+}
+
+// DeleteTag removes a tag from the IFD and reports whether it was there.
+func (ifd *ImageFileDirectory) DeleteTag(tagID uint16) bool {
+	i := ifd.entryIndex(tagID)
+	if i == len(ifd.Entries) || ifd.Entries[i].TagID != tagID {
+		return false
+	}
+	ifd.Entries = append(ifd.Entries[:i], ifd.Entries[i+1:]...)
+	ifd.indexEntries()
+	return true
+}
+
+// ZeroTag sets the value of a tag to zeros, keeping its type, count and
+// offset, and reports whether the tag was there.
+func (ifd *ImageFileDirectory) ZeroTag(tagID uint16) bool {
+	entry := ifd.TagsById[tagID]
+	if entry == nil {
+		return false
+	}
+	if !entry.outOfLine() {
+		entry.DataOrOffset = 0
+		return true
+	}
+	values := ifd.ParentFile.ValuesByOffset
+	ifd.ParentFile.edited[entry.DataOrOffset] = true
+	switch v := values[entry.DataOrOffset].(type) {
+	case string:
+		values[entry.DataOrOffset] = strings.Repeat("\x00", len(v))
+	case []uint8:
+		values[entry.DataOrOffset] = make([]uint8, len(v))
+	case []uint16:
+		values[entry.DataOrOffset] = make([]uint16, len(v))
+	case []uint32:
+		values[entry.DataOrOffset] = make([]uint32, len(v))
+	case Rational:
+		values[entry.DataOrOffset] = Rational{}
+	case []Rational:
+		values[entry.DataOrOffset] = make([]Rational, len(v))
+	case SRational:
+		values[entry.DataOrOffset] = SRational{}
+	case []SRational:
+		values[entry.DataOrOffset] = make([]SRational, len(v))
+	}
+	return true
+}
+
+// UserComment returns the value of a UserComment tag holding comment, in the
+// ASCII character code.
+func UserComment(comment string) []byte {
+	return append([]byte("ASCII\x00\x00\x00"), comment...)
+}
+
+// setTag replaces or adds an entry. Values stored out of the entry are kept
+// in ValuesByOffset, under the offset of the previous value when no other
+// entry uses it: Write moves them to the end of the file when they no longer
+// fit there.
+func (ifd *ImageFileDirectory) setTag(tagID uint16, tagType uint16, value interface{}) {
+	cf := ifd.ParentFile
+	data, _ := encodeValue(tagType, value)
+	entry := IFDEntry{TagID: tagID, TagType: tagType, NumberOfValues: uint32(len(data) / tagTypeSize(tagType))}
+	if entry.outOfLine() {
+		entry.DataOrOffset = cf.valueKey(ifd.TagsById[tagID])
+		cf.ValuesByOffset[entry.DataOrOffset] = value
+		cf.edited[entry.DataOrOffset] = true
+	} else {
+		var inline [4]byte
+		copy(inline[:], data)
+		entry.DataOrOffset = binary.LittleEndian.Uint32(inline[:])
+	}
+
+	i := ifd.entryIndex(tagID)
+	if i == len(ifd.Entries) || ifd.Entries[i].TagID != tagID {
+		ifd.Entries = append(ifd.Entries, IFDEntry{})
+		copy(ifd.Entries[i+1:], ifd.Entries[i:])
+	}
+	ifd.Entries[i] = entry
+	ifd.indexEntries()
+}
+
+// entryIndex returns the position of a tag in the entries, sorted by tag as
+// TIFF requires, or where it would be inserted.
+func (ifd *ImageFileDirectory) entryIndex(tagID uint16) int {
+	return sort.Search(len(ifd.Entries), func(i int) bool {
+		return ifd.Entries[i].TagID >= tagID
+	})
+}
+
+func (ifd *ImageFileDirectory) indexEntries() {
+	ifd.NumberOfEntries = uint16(len(ifd.Entries))
+	ifd.TagsById = make(map[uint16]*IFDEntry)
+	ifd.TagsByName = make(map[string]*IFDEntry)
+	for i := range ifd.Entries {
+		entry := &ifd.Entries[i]
+		ifd.TagsById[entry.TagID] = entry
+		ifd.TagsByName[ifd.resolver(entry.TagID)] = entry
+	}
+}
+
+// valueKey returns the key in ValuesByOffset for a new value of an entry: the
+// offset of its previous value when it is not shared with another entry, or
+// else a key which is not the offset of any value of the file. Those keys
+// count down from 0xFFFFFFFF, above the values of any file smaller than 4 GiB,
+// and skip the offsets of the values read, so that Write never encodes a new
+// value over one of the file.
+func (cf *CR2File) valueKey(previous *IFDEntry) uint32 {
+	if previous != nil && previous.outOfLine() && cf.ValuesByOffset[previous.DataOrOffset] != nil {
+		users := 0
+		for _, ifd := range cf.ifds() {
+			for _, entry := range ifd.Entries {
+				if entry.outOfLine() && entry.DataOrOffset == previous.DataOrOffset {
+					users++
+				}
+			}
+		}
+		if users == 1 {
+			return previous.DataOrOffset
+		}
+	}
+	for {
+		// wraps to 0xFFFFFFFF on the first call
+		cf.lastValueKey--
+		if _, read := cf.valueSizes[cf.lastValueKey]; !read && cf.ValuesByOffset[cf.lastValueKey] == nil {
+			return cf.lastValueKey
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package cr2
+
+import "testing"
+
+// Keys of new values count down from 0xFFFFFFFF, skipping the offsets of the
+// values read.
+func TestValueKey(t *testing.T) {
+	cf := openBytes(t, readFixture(t))
+	if key := cf.valueKey(nil); key != 0xffffffff {
+		t.Errorf("first key %#x, want 0xffffffff", key)
+	}
+
+	cf = openBytes(t, readFixture(t))
+	cf.valueSizes[0xffffffff] = 4
+	cf.ValuesByOffset[0xfffffffd] = []uint16{1, 2, 3}
+	for _, want := range []uint32{0xfffffffe, 0xfffffffc, 0xfffffffb} {
+		if key := cf.valueKey(nil); key != want {
+			t.Errorf("key %#x, want %#x", key, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package cr2
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBadMagic             = errors.New("cr2: bad magic number")
+	ErrUnsupportedVersion   = errors.New("cr2: unsupported CR2 version")
+	ErrUnsupportedByteOrder = errors.New("cr2: unsupported byte order")
+	ErrSRaw                 = errors.New("cr2: sRAW/mRAW file has no CFA data")
+	ErrNotSRaw              = errors.New("cr2: not an sRAW/mRAW file")
+	ErrNoGPS                = errors.New("cr2: file has no GPS position")
+)
+
+// FormatError reports a structural problem found while parsing a CR2 file.
+// Offset is the file (or stream) position where the problem was detected and
+// Section names the header, IFD or image being read at that time.
+type FormatError struct {
+	Offset  int64
+	Section string
+	Msg     string
+	Err     error
+}
+
+func (e *FormatError) Error() string {
+	msg := e.Msg
+	if e.Err != nil {
+		if msg != "" {
+			msg += ": "
+		}
+		msg += e.Err.Error()
+	}
+	return fmt.Sprintf("cr2: %s@%d: %s", e.Section, e.Offset, msg)
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+func formatErrorf(offset int64, section string, format string, args ...interface{}) error {
+	return &FormatError{Offset: offset, Section: section, Msg: fmt.Sprintf(format, args...)}
+}
+
+func wrapError(offset int64, section string, err error) error {
+	if _, ok := err.(*FormatError); ok {
+		return err
+	}
+	if err == ErrBadMagic || err == ErrUnsupportedVersion || err == ErrUnsupportedByteOrder || err == ErrSRaw || err == ErrNotSRaw {
+		return err
+	}
+	return &FormatError{Offset: offset, Section: section, Err: err}
+}
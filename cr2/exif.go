@@ -1,7 +1,16 @@
 package cr2
 
+//go:generate go run gen_exiftags.go
+
 const ExifPhotoMakerNote = 0x927c
 const ExifImageExifTag = 0x8769
+const ExifImageGPSTag = 0x8825
+const ExifImageArtist = 0x013b
+const ExifImageCopyright = 0x8298
+const ExifPhotoUserComment = 0x9286
+const ExifPhotoCameraOwnerName = 0xa430
+const ExifPhotoBodySerialNumber = 0xa431
+const ExifPhotoLensSerialNumber = 0xa435
 const ExifImageCR2Slice = 0xC640
 const ExifImageThumbnailOffset = 0x0201
 const ExifImageThumbnailLength = 0x0202
@@ -9,71 +18,49 @@ const ExifImageStripOffset = 0x0111
 const ExifImageStripBytesCount = 0x0117
 const ExifImageWidth = 0x0100
 const ExifImageHeight = 0x0101
-
-var KnownExifTags = map[uint16]string{
-	0x0100: "Exif.Image.ImageWidth",
-	0x0101: "Exif.Image.ImageHeight",
-	0x0102: "Exif.Image.BitsPerSample",
-	0x0103: "Exif.Image.Compression",
-	0x0106: "Exif.Image.PhotometricInterpretation",
-	0x010f: "Exif.Image.Make",
-	0x0110: "Exif.Image.Model",
-	0x0111: "Exif.Image.StripOffsets",
-	0x0112: "Exif.Image.Orientation",
-	0x0115: "Exif.Image.SamplesPerPixel",
-	0x0116: "Exif.Image.RowsPerStrip",
-	0x0117: "Exif.Image.StripByteCounts",
-	0x011a: "Exif.Image.XResolution",
-	0x011b: "Exif.Image.YResolution",
-	0x011c: "Exif.Image.PlanarConfiguration",
-	0x0128: "Exif.Image.ResolutionUnit",
-	0x0132: "Exif.Image.DateTime",
-	0x013b: "Exif.Image.Artist",
-	0x02bc: "Exif.Image.XMLPacket",
-	0x0201: "Exif.Image.ThumbnailOffset",
-	0x0202: "Exif.Image.ThumbnailLength",
-	0x8298: "Exif.Image.Copyright",
-	0x829a: "Exif.Image.ExposureTime",
-	0x8769: "Exif.Image.ExifTag",
-	0x829d: "Exif.Image.FNumber",
-	0x8822: "Exif.Image.ExposureProgram",
-	0x8825: "Exif.Image.GPSTag",
-	0x8827: "Exif.Image.ISOSpeedRatings",
-	0x8830: "Exif.Image.SensitivityType",
-	0x8832: "Exif.Image.RecommendedExposureIndex",
-	0x9000: "Exif.Photo.ExifVersion",
-	0x9003: "Exif.Photo.DateTimeOriginal",
-	0x9004: "Exif.Photo.DateTimeDigitized",
-	0x9101: "Exif.Photo.ComponentsConfiguration",
-	0x9201: "Exif.Photo.ShutterSpeedValue",
-	0x9202: "Exif.Photo.ApertureValue",
-	0x9204: "Exif.Photo.ExposureBiasValue",
-	0x9207: "Exif.Photo.MeteringMode",
-	0x9209: "Exif.Photo.Flash",
-	0x920a: "Exif.Photo.FocalLength",
-	0x927c: "Exif.Photo.MakerNote",
-	0x9286: "Exif.Photo.UserComment",
-	0x9290: "Exif.Photo.SubSecTime",
-	0x9291: "Exif.Photo.SubSecTimeOriginal",
-	0x9292: "Exif.Photo.SubSecTimeDigitized",
-	0xa000: "Exif.Photo.FlashpixVersion",
-	0xa001: "Exif.Photo.ColorSpace",
-	0xa002: "Exif.Photo.PixelXDimension",
-	0xa003: "Exif.Photo.PixelYDimension",
-	0xa005: "Exif.Photo.InteroperabilityTag",
-	0xa20e: "Exif.Photo.FocalPlaneXResolution",
-	0xa20f: "Exif.Photo.FocalPlaneYResolution",
-	0xa210: "Exif.Photo.FocalPlaneResolutionUnit",
-	0xa401: "Exif.Photo.CustomRendered",
-	0xa402: "Exif.Photo.ExposureMode",
-	0xa403: "Exif.Photo.WhiteBalance",
-	0xa406: "Exif.Photo.SceneCaptureType",
-	0xa430: "Exif.Photo.CameraOwnerName",
-	0xa431: "Exif.Photo.BodySerialNumber",
-	0xa432: "Exif.Photo.LensSpecification",
-	0xa434: "Exif.Photo.LensModel",
-	0xa435: "Exif.Photo.LensSerialNumber",
-}
+const ExifImageMake = 0x010f
+const ExifImageModel = 0x0110
+const ExifImageOrientation = 0x0112
+const ExifPhotoExposureTime = 0x829a
+const ExifPhotoFNumber = 0x829d
+const ExifPhotoISOSpeedRatings = 0x8827
+const ExifPhotoRecommendedExposureIndex = 0x8832
+const ExifPhotoDateTimeOriginal = 0x9003
+const ExifPhotoOffsetTimeOriginal = 0x9011
+const ExifPhotoExposureBiasValue = 0x9204
+const ExifPhotoMeteringMode = 0x9207
+const ExifPhotoFlash = 0x9209
+const ExifPhotoFocalLength = 0x920a
+const ExifPhotoSubSecTimeOriginal = 0x9291
+const ExifPhotoPixelXDimension = 0xa002
+const ExifPhotoPixelYDimension = 0xa003
+const ExifPhotoLensSpecification = 0xa432
+const ExifPhotoLensModel = 0xa434
+const ExifPhotoInteroperabilityTag = 0xa005
+const ExifCanonCameraSettings = 0x0001
+const ExifCanonShotInfo = 0x0004
+const ExifCanonFirmwareVersion = 0x0007
+const ExifCanonOwnerName = 0x0009
+const ExifCanonSerialNumber = 0x000c
+const ExifCanonModelID = 0x0010
+const ExifCanonAFInfo2 = 0x0026
+const ExifCanonLensModel = 0x0095
+const ExifCanonInternalSerialNumber = 0x0096
+const ExifCanonSensorInfo = 0x00e0
+const ExifCanonColorData = 0x4001
+const ExifCanonLensInfo = 0x4019
+const ExifGPSLatitudeRef = 0x0001
+const ExifGPSLatitude = 0x0002
+const ExifGPSLongitudeRef = 0x0003
+const ExifGPSLongitude = 0x0004
+const ExifGPSAltitudeRef = 0x0005
+const ExifGPSAltitude = 0x0006
+const ExifGPSTimeStamp = 0x0007
+const ExifGPSSatellites = 0x0008
+const ExifGPSImgDirectionRef = 0x0010
+const ExifGPSImgDirection = 0x0011
+const ExifGPSMapDatum = 0x0012
+const ExifGPSDateStamp = 0x001d
 
 var KnownCanonTags = map[uint16]string{
 	0x0001: "Exif.Canon.CameraSettings",
@@ -83,6 +70,7 @@ var KnownCanonTags = map[uint16]string{
 	0x0006: "Exif.Canon.ImageType",
 	0x0007: "Exif.Canon.FirmwareVersion",
 	0x0009: "Exif.Canon.OwnerName",
+	0x000c: "Exif.Canon.SerialNumber",
 	0x000d: "Exif.Canon.CameraInfo",
 	0x0010: "Exif.Canon.CanonModelID",
 	0x0013: "Exif.Canon.ThumbnailImageValidArea",
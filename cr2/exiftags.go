@@ -0,0 +1,678 @@
+// Code generated by go run gen_exiftags.go; DO NOT EDIT.
+
+package cr2
+
+var KnownExifTags = map[uint16]string{
+	0x00fe: "Exif.Image.NewSubfileType",
+	0x00ff: "Exif.Image.SubfileType",
+	0x0100: "Exif.Image.ImageWidth",
+	0x0101: "Exif.Image.ImageHeight",
+	0x0102: "Exif.Image.BitsPerSample",
+	0x0103: "Exif.Image.Compression",
+	0x0106: "Exif.Image.PhotometricInterpretation",
+	0x0107: "Exif.Image.Thresholding",
+	0x0108: "Exif.Image.CellWidth",
+	0x0109: "Exif.Image.CellLength",
+	0x010a: "Exif.Image.FillOrder",
+	0x010d: "Exif.Image.DocumentName",
+	0x010e: "Exif.Image.ImageDescription",
+	0x010f: "Exif.Image.Make",
+	0x0110: "Exif.Image.Model",
+	0x0111: "Exif.Image.StripOffsets",
+	0x0112: "Exif.Image.Orientation",
+	0x0115: "Exif.Image.SamplesPerPixel",
+	0x0116: "Exif.Image.RowsPerStrip",
+	0x0117: "Exif.Image.StripByteCounts",
+	0x0118: "Exif.Image.MinSampleValue",
+	0x0119: "Exif.Image.MaxSampleValue",
+	0x011a: "Exif.Image.XResolution",
+	0x011b: "Exif.Image.YResolution",
+	0x011c: "Exif.Image.PlanarConfiguration",
+	0x011d: "Exif.Image.PageName",
+	0x011e: "Exif.Image.XPosition",
+	0x011f: "Exif.Image.YPosition",
+	0x0120: "Exif.Image.FreeOffsets",
+	0x0121: "Exif.Image.FreeByteCounts",
+	0x0122: "Exif.Image.GrayResponseUnit",
+	0x0123: "Exif.Image.GrayResponseCurve",
+	0x0124: "Exif.Image.T4Options",
+	0x0125: "Exif.Image.T6Options",
+	0x0128: "Exif.Image.ResolutionUnit",
+	0x0129: "Exif.Image.PageNumber",
+	0x012d: "Exif.Image.TransferFunction",
+	0x0131: "Exif.Image.Software",
+	0x0132: "Exif.Image.DateTime",
+	0x013b: "Exif.Image.Artist",
+	0x013c: "Exif.Image.HostComputer",
+	0x013d: "Exif.Image.Predictor",
+	0x013e: "Exif.Image.WhitePoint",
+	0x013f: "Exif.Image.PrimaryChromaticities",
+	0x0140: "Exif.Image.ColorMap",
+	0x0141: "Exif.Image.HalftoneHints",
+	0x0142: "Exif.Image.TileWidth",
+	0x0143: "Exif.Image.TileLength",
+	0x0144: "Exif.Image.TileOffsets",
+	0x0145: "Exif.Image.TileByteCounts",
+	0x014c: "Exif.Image.InkSet",
+	0x014d: "Exif.Image.InkNames",
+	0x014e: "Exif.Image.NumberOfInks",
+	0x0150: "Exif.Image.DotRange",
+	0x0151: "Exif.Image.TargetPrinter",
+	0x0152: "Exif.Image.ExtraSamples",
+	0x0153: "Exif.Image.SampleFormat",
+	0x0156: "Exif.Image.TransferRange",
+	0x0200: "Exif.Image.JPEGProc",
+	0x0201: "Exif.Image.ThumbnailOffset",
+	0x0202: "Exif.Image.ThumbnailLength",
+	0x0203: "Exif.Image.JPEGRestartInterval",
+	0x0205: "Exif.Image.JPEGLosslessPredictors",
+	0x0206: "Exif.Image.JPEGPointTransforms",
+	0x0207: "Exif.Image.JPEGQTables",
+	0x0208: "Exif.Image.JPEGDCTables",
+	0x0209: "Exif.Image.JPEGACTables",
+	0x0211: "Exif.Image.YCbCrCoefficients",
+	0x0212: "Exif.Image.YCbCrSubSampling",
+	0x0213: "Exif.Image.YCbCrPositioning",
+	0x0214: "Exif.Image.ReferenceBlackWhite",
+	0x02bc: "Exif.Image.XMLPacket",
+	0x4746: "Exif.Image.Rating",
+	0x4749: "Exif.Image.RatingPercent",
+	0x8298: "Exif.Image.Copyright",
+	0x829a: "Exif.Photo.ExposureTime",
+	0x829d: "Exif.Photo.FNumber",
+	0x83bb: "Exif.Image.IPTCNAA",
+	0x8649: "Exif.Image.ImageResources",
+	0x8769: "Exif.Image.ExifTag",
+	0x8822: "Exif.Photo.ExposureProgram",
+	0x8824: "Exif.Photo.SpectralSensitivity",
+	0x8825: "Exif.Image.GPSTag",
+	0x8827: "Exif.Photo.ISOSpeedRatings",
+	0x8828: "Exif.Photo.OECF",
+	0x8830: "Exif.Photo.SensitivityType",
+	0x8831: "Exif.Photo.StandardOutputSensitivity",
+	0x8832: "Exif.Photo.RecommendedExposureIndex",
+	0x8833: "Exif.Photo.ISOSpeed",
+	0x8834: "Exif.Photo.ISOSpeedLatitudeyyy",
+	0x8835: "Exif.Photo.ISOSpeedLatitudezzz",
+	0x9000: "Exif.Photo.ExifVersion",
+	0x9003: "Exif.Photo.DateTimeOriginal",
+	0x9004: "Exif.Photo.DateTimeDigitized",
+	0x9010: "Exif.Photo.OffsetTime",
+	0x9011: "Exif.Photo.OffsetTimeOriginal",
+	0x9012: "Exif.Photo.OffsetTimeDigitized",
+	0x9101: "Exif.Photo.ComponentsConfiguration",
+	0x9102: "Exif.Photo.CompressedBitsPerPixel",
+	0x9201: "Exif.Photo.ShutterSpeedValue",
+	0x9202: "Exif.Photo.ApertureValue",
+	0x9203: "Exif.Photo.BrightnessValue",
+	0x9204: "Exif.Photo.ExposureBiasValue",
+	0x9205: "Exif.Photo.MaxApertureValue",
+	0x9206: "Exif.Photo.SubjectDistance",
+	0x9207: "Exif.Photo.MeteringMode",
+	0x9208: "Exif.Photo.LightSource",
+	0x9209: "Exif.Photo.Flash",
+	0x920a: "Exif.Photo.FocalLength",
+	0x9214: "Exif.Photo.SubjectArea",
+	0x927c: "Exif.Photo.MakerNote",
+	0x9286: "Exif.Photo.UserComment",
+	0x9290: "Exif.Photo.SubSecTime",
+	0x9291: "Exif.Photo.SubSecTimeOriginal",
+	0x9292: "Exif.Photo.SubSecTimeDigitized",
+	0x9400: "Exif.Photo.Temperature",
+	0x9401: "Exif.Photo.Humidity",
+	0x9402: "Exif.Photo.Pressure",
+	0x9403: "Exif.Photo.WaterDepth",
+	0x9404: "Exif.Photo.Acceleration",
+	0x9405: "Exif.Photo.CameraElevationAngle",
+	0xa000: "Exif.Photo.FlashpixVersion",
+	0xa001: "Exif.Photo.ColorSpace",
+	0xa002: "Exif.Photo.PixelXDimension",
+	0xa003: "Exif.Photo.PixelYDimension",
+	0xa004: "Exif.Photo.RelatedSoundFile",
+	0xa005: "Exif.Photo.InteroperabilityTag",
+	0xa20b: "Exif.Photo.FlashEnergy",
+	0xa20c: "Exif.Photo.SpatialFrequencyResponse",
+	0xa20e: "Exif.Photo.FocalPlaneXResolution",
+	0xa20f: "Exif.Photo.FocalPlaneYResolution",
+	0xa210: "Exif.Photo.FocalPlaneResolutionUnit",
+	0xa214: "Exif.Photo.SubjectLocation",
+	0xa215: "Exif.Photo.ExposureIndex",
+	0xa217: "Exif.Photo.SensingMethod",
+	0xa300: "Exif.Photo.FileSource",
+	0xa301: "Exif.Photo.SceneType",
+	0xa302: "Exif.Photo.CFAPattern",
+	0xa401: "Exif.Photo.CustomRendered",
+	0xa402: "Exif.Photo.ExposureMode",
+	0xa403: "Exif.Photo.WhiteBalance",
+	0xa404: "Exif.Photo.DigitalZoomRatio",
+	0xa405: "Exif.Photo.FocalLengthIn35mmFilm",
+	0xa406: "Exif.Photo.SceneCaptureType",
+	0xa407: "Exif.Photo.GainControl",
+	0xa408: "Exif.Photo.Contrast",
+	0xa409: "Exif.Photo.Saturation",
+	0xa40a: "Exif.Photo.Sharpness",
+	0xa40b: "Exif.Photo.DeviceSettingDescription",
+	0xa40c: "Exif.Photo.SubjectDistanceRange",
+	0xa420: "Exif.Photo.ImageUniqueID",
+	0xa430: "Exif.Photo.CameraOwnerName",
+	0xa431: "Exif.Photo.BodySerialNumber",
+	0xa432: "Exif.Photo.LensSpecification",
+	0xa433: "Exif.Photo.LensMake",
+	0xa434: "Exif.Photo.LensModel",
+	0xa435: "Exif.Photo.LensSerialNumber",
+	0xa460: "Exif.Photo.CompositeImage",
+	0xa461: "Exif.Photo.SourceImageNumberOfCompositeImage",
+	0xa462: "Exif.Photo.SourceExposureTimesOfCompositeImage",
+	0xa500: "Exif.Photo.Gamma",
+	0xc640: "Exif.Image.CR2Slice",
+}
+
+var KnownGPSTags = map[uint16]string{
+	0x0000: "Exif.GPSInfo.GPSVersionID",
+	0x0001: "Exif.GPSInfo.GPSLatitudeRef",
+	0x0002: "Exif.GPSInfo.GPSLatitude",
+	0x0003: "Exif.GPSInfo.GPSLongitudeRef",
+	0x0004: "Exif.GPSInfo.GPSLongitude",
+	0x0005: "Exif.GPSInfo.GPSAltitudeRef",
+	0x0006: "Exif.GPSInfo.GPSAltitude",
+	0x0007: "Exif.GPSInfo.GPSTimeStamp",
+	0x0008: "Exif.GPSInfo.GPSSatellites",
+	0x0009: "Exif.GPSInfo.GPSStatus",
+	0x000a: "Exif.GPSInfo.GPSMeasureMode",
+	0x000b: "Exif.GPSInfo.GPSDOP",
+	0x000c: "Exif.GPSInfo.GPSSpeedRef",
+	0x000d: "Exif.GPSInfo.GPSSpeed",
+	0x000e: "Exif.GPSInfo.GPSTrackRef",
+	0x000f: "Exif.GPSInfo.GPSTrack",
+	0x0010: "Exif.GPSInfo.GPSImgDirectionRef",
+	0x0011: "Exif.GPSInfo.GPSImgDirection",
+	0x0012: "Exif.GPSInfo.GPSMapDatum",
+	0x0013: "Exif.GPSInfo.GPSDestLatitudeRef",
+	0x0014: "Exif.GPSInfo.GPSDestLatitude",
+	0x0015: "Exif.GPSInfo.GPSDestLongitudeRef",
+	0x0016: "Exif.GPSInfo.GPSDestLongitude",
+	0x0017: "Exif.GPSInfo.GPSDestBearingRef",
+	0x0018: "Exif.GPSInfo.GPSDestBearing",
+	0x0019: "Exif.GPSInfo.GPSDestDistanceRef",
+	0x001a: "Exif.GPSInfo.GPSDestDistance",
+	0x001b: "Exif.GPSInfo.GPSProcessingMethod",
+	0x001c: "Exif.GPSInfo.GPSAreaInformation",
+	0x001d: "Exif.GPSInfo.GPSDateStamp",
+	0x001e: "Exif.GPSInfo.GPSDifferential",
+	0x001f: "Exif.GPSInfo.GPSHPositioningError",
+}
+
+var KnownInteropTags = map[uint16]string{
+	0x0001: "Exif.Iop.InteroperabilityIndex",
+	0x0002: "Exif.Iop.InteroperabilityVersion",
+	0x1000: "Exif.Iop.RelatedImageFileFormat",
+	0x1001: "Exif.Iop.RelatedImageWidth",
+	0x1002: "Exif.Iop.RelatedImageLength",
+}
+
+var tagInfos = map[string]*TagInfo{
+	"Exif.GPSInfo.GPSVersionID":      {Name: "Exif.GPSInfo.GPSVersionID", Types: []uint16{1}, Count: 4},
+	"Exif.Iop.InteroperabilityIndex": {Name: "Exif.Iop.InteroperabilityIndex", Types: []uint16{2}, Count: 0},
+	"Exif.GPSInfo.GPSLatitudeRef": {Name: "Exif.GPSInfo.GPSLatitudeRef", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"N": "North",
+		"S": "South",
+	}},
+	"Exif.Iop.InteroperabilityVersion": {Name: "Exif.Iop.InteroperabilityVersion", Types: []uint16{7}, Count: 4},
+	"Exif.GPSInfo.GPSLatitude":         {Name: "Exif.GPSInfo.GPSLatitude", Types: []uint16{5}, Count: 3},
+	"Exif.GPSInfo.GPSLongitudeRef": {Name: "Exif.GPSInfo.GPSLongitudeRef", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"E": "East",
+		"W": "West",
+	}},
+	"Exif.GPSInfo.GPSLongitude": {Name: "Exif.GPSInfo.GPSLongitude", Types: []uint16{5}, Count: 3},
+	"Exif.GPSInfo.GPSAltitudeRef": {Name: "Exif.GPSInfo.GPSAltitudeRef", Types: []uint16{1}, Count: 1, Values: map[string]string{
+		"0": "Above sea level",
+		"1": "Below sea level",
+	}},
+	"Exif.GPSInfo.GPSAltitude":   {Name: "Exif.GPSInfo.GPSAltitude", Types: []uint16{5}, Count: 1},
+	"Exif.GPSInfo.GPSTimeStamp":  {Name: "Exif.GPSInfo.GPSTimeStamp", Types: []uint16{5}, Count: 3},
+	"Exif.GPSInfo.GPSSatellites": {Name: "Exif.GPSInfo.GPSSatellites", Types: []uint16{2}, Count: 0},
+	"Exif.GPSInfo.GPSStatus": {Name: "Exif.GPSInfo.GPSStatus", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"A": "Measurement in progress",
+		"V": "Measurement interrupted",
+	}},
+	"Exif.GPSInfo.GPSMeasureMode": {Name: "Exif.GPSInfo.GPSMeasureMode", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"2": "Two-dimensional measurement",
+		"3": "Three-dimensional measurement",
+	}},
+	"Exif.GPSInfo.GPSDOP": {Name: "Exif.GPSInfo.GPSDOP", Types: []uint16{5}, Count: 1},
+	"Exif.GPSInfo.GPSSpeedRef": {Name: "Exif.GPSInfo.GPSSpeedRef", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"K": "km/h",
+		"M": "mph",
+		"N": "knots",
+	}},
+	"Exif.GPSInfo.GPSSpeed": {Name: "Exif.GPSInfo.GPSSpeed", Types: []uint16{5}, Count: 1},
+	"Exif.GPSInfo.GPSTrackRef": {Name: "Exif.GPSInfo.GPSTrackRef", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"T": "True direction",
+		"M": "Magnetic direction",
+	}},
+	"Exif.GPSInfo.GPSTrack": {Name: "Exif.GPSInfo.GPSTrack", Types: []uint16{5}, Count: 1},
+	"Exif.GPSInfo.GPSImgDirectionRef": {Name: "Exif.GPSInfo.GPSImgDirectionRef", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"T": "True direction",
+		"M": "Magnetic direction",
+	}},
+	"Exif.GPSInfo.GPSImgDirection": {Name: "Exif.GPSInfo.GPSImgDirection", Types: []uint16{5}, Count: 1},
+	"Exif.GPSInfo.GPSMapDatum":     {Name: "Exif.GPSInfo.GPSMapDatum", Types: []uint16{2}, Count: 0},
+	"Exif.GPSInfo.GPSDestLatitudeRef": {Name: "Exif.GPSInfo.GPSDestLatitudeRef", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"N": "North",
+		"S": "South",
+	}},
+	"Exif.GPSInfo.GPSDestLatitude": {Name: "Exif.GPSInfo.GPSDestLatitude", Types: []uint16{5}, Count: 3},
+	"Exif.GPSInfo.GPSDestLongitudeRef": {Name: "Exif.GPSInfo.GPSDestLongitudeRef", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"E": "East",
+		"W": "West",
+	}},
+	"Exif.GPSInfo.GPSDestLongitude": {Name: "Exif.GPSInfo.GPSDestLongitude", Types: []uint16{5}, Count: 3},
+	"Exif.GPSInfo.GPSDestBearingRef": {Name: "Exif.GPSInfo.GPSDestBearingRef", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"T": "True direction",
+		"M": "Magnetic direction",
+	}},
+	"Exif.GPSInfo.GPSDestBearing": {Name: "Exif.GPSInfo.GPSDestBearing", Types: []uint16{5}, Count: 1},
+	"Exif.GPSInfo.GPSDestDistanceRef": {Name: "Exif.GPSInfo.GPSDestDistanceRef", Types: []uint16{2}, Count: 2, Values: map[string]string{
+		"K": "Kilometers",
+		"M": "Miles",
+		"N": "Nautical miles",
+	}},
+	"Exif.GPSInfo.GPSDestDistance":     {Name: "Exif.GPSInfo.GPSDestDistance", Types: []uint16{5}, Count: 1},
+	"Exif.GPSInfo.GPSProcessingMethod": {Name: "Exif.GPSInfo.GPSProcessingMethod", Types: []uint16{7}, Count: 0},
+	"Exif.GPSInfo.GPSAreaInformation":  {Name: "Exif.GPSInfo.GPSAreaInformation", Types: []uint16{7}, Count: 0},
+	"Exif.GPSInfo.GPSDateStamp":        {Name: "Exif.GPSInfo.GPSDateStamp", Types: []uint16{2}, Count: 11},
+	"Exif.GPSInfo.GPSDifferential": {Name: "Exif.GPSInfo.GPSDifferential", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "No correction",
+		"1": "Differential corrected",
+	}},
+	"Exif.GPSInfo.GPSHPositioningError": {Name: "Exif.GPSInfo.GPSHPositioningError", Types: []uint16{5}, Count: 1},
+	"Exif.Image.NewSubfileType": {Name: "Exif.Image.NewSubfileType", Types: []uint16{4}, Count: 1, Values: map[string]string{
+		"0": "Primary image",
+		"1": "Thumbnail/Preview image",
+		"2": "Primary image, Multi page file",
+		"3": "Thumbnail/Preview image, Multi page file",
+	}},
+	"Exif.Image.SubfileType": {Name: "Exif.Image.SubfileType", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "Full-resolution image",
+		"2": "Reduced-resolution image",
+		"3": "Single page of multi-page image",
+	}},
+	"Exif.Image.ImageWidth":    {Name: "Exif.Image.ImageWidth", Types: []uint16{3, 4}, Count: 1},
+	"Exif.Image.ImageHeight":   {Name: "Exif.Image.ImageHeight", Types: []uint16{3, 4}, Count: 1},
+	"Exif.Image.BitsPerSample": {Name: "Exif.Image.BitsPerSample", Types: []uint16{3}, Count: 0},
+	"Exif.Image.Compression": {Name: "Exif.Image.Compression", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1":     "Uncompressed",
+		"2":     "CCITT 1D",
+		"3":     "T4/Group 3 Fax",
+		"4":     "T6/Group 4 Fax",
+		"5":     "LZW",
+		"6":     "JPEG (old-style)",
+		"7":     "JPEG",
+		"8":     "Adobe Deflate",
+		"32773": "PackBits",
+	}},
+	"Exif.Image.PhotometricInterpretation": {Name: "Exif.Image.PhotometricInterpretation", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0":     "WhiteIsZero",
+		"1":     "BlackIsZero",
+		"2":     "RGB",
+		"3":     "RGB Palette",
+		"4":     "Transparency Mask",
+		"5":     "CMYK",
+		"6":     "YCbCr",
+		"8":     "CIELab",
+		"32803": "Color Filter Array",
+		"34892": "Linear Raw",
+	}},
+	"Exif.Image.Thresholding": {Name: "Exif.Image.Thresholding", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "No dithering or halftoning",
+		"2": "Ordered dither or halftone",
+		"3": "Randomized dither",
+	}},
+	"Exif.Image.CellWidth":  {Name: "Exif.Image.CellWidth", Types: []uint16{3}, Count: 1},
+	"Exif.Image.CellLength": {Name: "Exif.Image.CellLength", Types: []uint16{3}, Count: 1},
+	"Exif.Image.FillOrder": {Name: "Exif.Image.FillOrder", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "Normal",
+		"2": "Reversed",
+	}},
+	"Exif.Image.DocumentName":     {Name: "Exif.Image.DocumentName", Types: []uint16{2}, Count: 0},
+	"Exif.Image.ImageDescription": {Name: "Exif.Image.ImageDescription", Types: []uint16{2}, Count: 0},
+	"Exif.Image.Make":             {Name: "Exif.Image.Make", Types: []uint16{2}, Count: 0},
+	"Exif.Image.Model":            {Name: "Exif.Image.Model", Types: []uint16{2}, Count: 0},
+	"Exif.Image.StripOffsets":     {Name: "Exif.Image.StripOffsets", Types: []uint16{3, 4}, Count: 0},
+	"Exif.Image.Orientation": {Name: "Exif.Image.Orientation", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "Horizontal (normal)",
+		"2": "Mirror horizontal",
+		"3": "Rotate 180",
+		"4": "Mirror vertical",
+		"5": "Mirror horizontal and rotate 270 CW",
+		"6": "Rotate 90 CW",
+		"7": "Mirror horizontal and rotate 90 CW",
+		"8": "Rotate 270 CW",
+	}},
+	"Exif.Image.SamplesPerPixel": {Name: "Exif.Image.SamplesPerPixel", Types: []uint16{3}, Count: 1},
+	"Exif.Image.RowsPerStrip":    {Name: "Exif.Image.RowsPerStrip", Types: []uint16{3, 4}, Count: 1},
+	"Exif.Image.StripByteCounts": {Name: "Exif.Image.StripByteCounts", Types: []uint16{3, 4}, Count: 0},
+	"Exif.Image.MinSampleValue":  {Name: "Exif.Image.MinSampleValue", Types: []uint16{3}, Count: 0},
+	"Exif.Image.MaxSampleValue":  {Name: "Exif.Image.MaxSampleValue", Types: []uint16{3}, Count: 0},
+	"Exif.Image.XResolution":     {Name: "Exif.Image.XResolution", Types: []uint16{5}, Count: 1},
+	"Exif.Image.YResolution":     {Name: "Exif.Image.YResolution", Types: []uint16{5}, Count: 1},
+	"Exif.Image.PlanarConfiguration": {Name: "Exif.Image.PlanarConfiguration", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "Chunky",
+		"2": "Planar",
+	}},
+	"Exif.Image.PageName":       {Name: "Exif.Image.PageName", Types: []uint16{2}, Count: 0},
+	"Exif.Image.XPosition":      {Name: "Exif.Image.XPosition", Types: []uint16{5}, Count: 1},
+	"Exif.Image.YPosition":      {Name: "Exif.Image.YPosition", Types: []uint16{5}, Count: 1},
+	"Exif.Image.FreeOffsets":    {Name: "Exif.Image.FreeOffsets", Types: []uint16{4}, Count: 0},
+	"Exif.Image.FreeByteCounts": {Name: "Exif.Image.FreeByteCounts", Types: []uint16{4}, Count: 0},
+	"Exif.Image.GrayResponseUnit": {Name: "Exif.Image.GrayResponseUnit", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "0.1",
+		"2": "0.01",
+		"3": "0.001",
+		"4": "0.0001",
+		"5": "0.00001",
+	}},
+	"Exif.Image.GrayResponseCurve": {Name: "Exif.Image.GrayResponseCurve", Types: []uint16{3}, Count: 0},
+	"Exif.Image.T4Options":         {Name: "Exif.Image.T4Options", Types: []uint16{4}, Count: 1},
+	"Exif.Image.T6Options":         {Name: "Exif.Image.T6Options", Types: []uint16{4}, Count: 1},
+	"Exif.Image.ResolutionUnit": {Name: "Exif.Image.ResolutionUnit", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "None",
+		"2": "Inch",
+		"3": "Centimeter",
+	}},
+	"Exif.Image.PageNumber":       {Name: "Exif.Image.PageNumber", Types: []uint16{3}, Count: 2},
+	"Exif.Image.TransferFunction": {Name: "Exif.Image.TransferFunction", Types: []uint16{3}, Count: 768},
+	"Exif.Image.Software":         {Name: "Exif.Image.Software", Types: []uint16{2}, Count: 0},
+	"Exif.Image.DateTime":         {Name: "Exif.Image.DateTime", Types: []uint16{2}, Count: 20},
+	"Exif.Image.Artist":           {Name: "Exif.Image.Artist", Types: []uint16{2}, Count: 0},
+	"Exif.Image.HostComputer":     {Name: "Exif.Image.HostComputer", Types: []uint16{2}, Count: 0},
+	"Exif.Image.Predictor": {Name: "Exif.Image.Predictor", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "None",
+		"2": "Horizontal differencing",
+	}},
+	"Exif.Image.WhitePoint":            {Name: "Exif.Image.WhitePoint", Types: []uint16{5}, Count: 2},
+	"Exif.Image.PrimaryChromaticities": {Name: "Exif.Image.PrimaryChromaticities", Types: []uint16{5}, Count: 6},
+	"Exif.Image.ColorMap":              {Name: "Exif.Image.ColorMap", Types: []uint16{3}, Count: 0},
+	"Exif.Image.HalftoneHints":         {Name: "Exif.Image.HalftoneHints", Types: []uint16{3}, Count: 2},
+	"Exif.Image.TileWidth":             {Name: "Exif.Image.TileWidth", Types: []uint16{3, 4}, Count: 1},
+	"Exif.Image.TileLength":            {Name: "Exif.Image.TileLength", Types: []uint16{3, 4}, Count: 1},
+	"Exif.Image.TileOffsets":           {Name: "Exif.Image.TileOffsets", Types: []uint16{4}, Count: 0},
+	"Exif.Image.TileByteCounts":        {Name: "Exif.Image.TileByteCounts", Types: []uint16{3, 4}, Count: 0},
+	"Exif.Image.InkSet": {Name: "Exif.Image.InkSet", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "CMYK",
+		"2": "Not CMYK",
+	}},
+	"Exif.Image.InkNames":      {Name: "Exif.Image.InkNames", Types: []uint16{2}, Count: 0},
+	"Exif.Image.NumberOfInks":  {Name: "Exif.Image.NumberOfInks", Types: []uint16{3}, Count: 1},
+	"Exif.Image.DotRange":      {Name: "Exif.Image.DotRange", Types: []uint16{1, 3}, Count: 0},
+	"Exif.Image.TargetPrinter": {Name: "Exif.Image.TargetPrinter", Types: []uint16{2}, Count: 0},
+	"Exif.Image.ExtraSamples": {Name: "Exif.Image.ExtraSamples", Types: []uint16{3}, Count: 0, Values: map[string]string{
+		"0": "Unspecified",
+		"1": "Associated alpha",
+		"2": "Unassociated alpha",
+	}},
+	"Exif.Image.SampleFormat": {Name: "Exif.Image.SampleFormat", Types: []uint16{3}, Count: 0, Values: map[string]string{
+		"1": "Unsigned integer",
+		"2": "Signed integer",
+		"3": "IEEE floating point",
+		"4": "Undefined",
+	}},
+	"Exif.Image.TransferRange": {Name: "Exif.Image.TransferRange", Types: []uint16{3}, Count: 6},
+	"Exif.Image.JPEGProc": {Name: "Exif.Image.JPEGProc", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1":  "Baseline",
+		"14": "Lossless",
+	}},
+	"Exif.Image.ThumbnailOffset":        {Name: "Exif.Image.ThumbnailOffset", Types: []uint16{4}, Count: 1},
+	"Exif.Image.ThumbnailLength":        {Name: "Exif.Image.ThumbnailLength", Types: []uint16{4}, Count: 1},
+	"Exif.Image.JPEGRestartInterval":    {Name: "Exif.Image.JPEGRestartInterval", Types: []uint16{3}, Count: 1},
+	"Exif.Image.JPEGLosslessPredictors": {Name: "Exif.Image.JPEGLosslessPredictors", Types: []uint16{3}, Count: 0},
+	"Exif.Image.JPEGPointTransforms":    {Name: "Exif.Image.JPEGPointTransforms", Types: []uint16{3}, Count: 0},
+	"Exif.Image.JPEGQTables":            {Name: "Exif.Image.JPEGQTables", Types: []uint16{4}, Count: 0},
+	"Exif.Image.JPEGDCTables":           {Name: "Exif.Image.JPEGDCTables", Types: []uint16{4}, Count: 0},
+	"Exif.Image.JPEGACTables":           {Name: "Exif.Image.JPEGACTables", Types: []uint16{4}, Count: 0},
+	"Exif.Image.YCbCrCoefficients":      {Name: "Exif.Image.YCbCrCoefficients", Types: []uint16{5}, Count: 3},
+	"Exif.Image.YCbCrSubSampling":       {Name: "Exif.Image.YCbCrSubSampling", Types: []uint16{3}, Count: 2},
+	"Exif.Image.YCbCrPositioning": {Name: "Exif.Image.YCbCrPositioning", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "Centered",
+		"2": "Co-sited",
+	}},
+	"Exif.Image.ReferenceBlackWhite":  {Name: "Exif.Image.ReferenceBlackWhite", Types: []uint16{5}, Count: 6},
+	"Exif.Image.XMLPacket":            {Name: "Exif.Image.XMLPacket", Types: []uint16{1, 7}, Count: 0},
+	"Exif.Iop.RelatedImageFileFormat": {Name: "Exif.Iop.RelatedImageFileFormat", Types: []uint16{2}, Count: 0},
+	"Exif.Iop.RelatedImageWidth":      {Name: "Exif.Iop.RelatedImageWidth", Types: []uint16{3, 4}, Count: 1},
+	"Exif.Iop.RelatedImageLength":     {Name: "Exif.Iop.RelatedImageLength", Types: []uint16{3, 4}, Count: 1},
+	"Exif.Image.Rating":               {Name: "Exif.Image.Rating", Types: []uint16{3}, Count: 1},
+	"Exif.Image.RatingPercent":        {Name: "Exif.Image.RatingPercent", Types: []uint16{3}, Count: 1},
+	"Exif.Image.Copyright":            {Name: "Exif.Image.Copyright", Types: []uint16{2}, Count: 0},
+	"Exif.Photo.ExposureTime":         {Name: "Exif.Photo.ExposureTime", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.FNumber":              {Name: "Exif.Photo.FNumber", Types: []uint16{5}, Count: 1},
+	"Exif.Image.IPTCNAA":              {Name: "Exif.Image.IPTCNAA", Types: []uint16{4, 7}, Count: 0},
+	"Exif.Image.ImageResources":       {Name: "Exif.Image.ImageResources", Types: []uint16{1, 7}, Count: 0},
+	"Exif.Image.ExifTag":              {Name: "Exif.Image.ExifTag", Types: []uint16{4}, Count: 1},
+	"Exif.Photo.ExposureProgram": {Name: "Exif.Photo.ExposureProgram", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Not defined",
+		"1": "Manual",
+		"2": "Normal program",
+		"3": "Aperture priority",
+		"4": "Shutter priority",
+		"5": "Creative program",
+		"6": "Action program",
+		"7": "Portrait mode",
+		"8": "Landscape mode",
+	}},
+	"Exif.Photo.SpectralSensitivity": {Name: "Exif.Photo.SpectralSensitivity", Types: []uint16{2}, Count: 0},
+	"Exif.Image.GPSTag":              {Name: "Exif.Image.GPSTag", Types: []uint16{4}, Count: 1},
+	"Exif.Photo.ISOSpeedRatings":     {Name: "Exif.Photo.ISOSpeedRatings", Types: []uint16{3}, Count: 0},
+	"Exif.Photo.OECF":                {Name: "Exif.Photo.OECF", Types: []uint16{7}, Count: 0},
+	"Exif.Photo.SensitivityType": {Name: "Exif.Photo.SensitivityType", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Unknown",
+		"1": "Standard output sensitivity",
+		"2": "Recommended exposure index",
+		"3": "ISO speed",
+		"4": "Standard output sensitivity and recommended exposure index",
+		"5": "Standard output sensitivity and ISO speed",
+		"6": "Recommended exposure index and ISO speed",
+		"7": "Standard output sensitivity, recommended exposure index and ISO speed",
+	}},
+	"Exif.Photo.StandardOutputSensitivity": {Name: "Exif.Photo.StandardOutputSensitivity", Types: []uint16{4}, Count: 1},
+	"Exif.Photo.RecommendedExposureIndex":  {Name: "Exif.Photo.RecommendedExposureIndex", Types: []uint16{4}, Count: 1},
+	"Exif.Photo.ISOSpeed":                  {Name: "Exif.Photo.ISOSpeed", Types: []uint16{4}, Count: 1},
+	"Exif.Photo.ISOSpeedLatitudeyyy":       {Name: "Exif.Photo.ISOSpeedLatitudeyyy", Types: []uint16{4}, Count: 1},
+	"Exif.Photo.ISOSpeedLatitudezzz":       {Name: "Exif.Photo.ISOSpeedLatitudezzz", Types: []uint16{4}, Count: 1},
+	"Exif.Photo.ExifVersion":               {Name: "Exif.Photo.ExifVersion", Types: []uint16{7}, Count: 4},
+	"Exif.Photo.DateTimeOriginal":          {Name: "Exif.Photo.DateTimeOriginal", Types: []uint16{2}, Count: 20},
+	"Exif.Photo.DateTimeDigitized":         {Name: "Exif.Photo.DateTimeDigitized", Types: []uint16{2}, Count: 20},
+	"Exif.Photo.OffsetTime":                {Name: "Exif.Photo.OffsetTime", Types: []uint16{2}, Count: 7},
+	"Exif.Photo.OffsetTimeOriginal":        {Name: "Exif.Photo.OffsetTimeOriginal", Types: []uint16{2}, Count: 7},
+	"Exif.Photo.OffsetTimeDigitized":       {Name: "Exif.Photo.OffsetTimeDigitized", Types: []uint16{2}, Count: 7},
+	"Exif.Photo.ComponentsConfiguration":   {Name: "Exif.Photo.ComponentsConfiguration", Types: []uint16{7}, Count: 4},
+	"Exif.Photo.CompressedBitsPerPixel":    {Name: "Exif.Photo.CompressedBitsPerPixel", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.ShutterSpeedValue":         {Name: "Exif.Photo.ShutterSpeedValue", Types: []uint16{10}, Count: 1},
+	"Exif.Photo.ApertureValue":             {Name: "Exif.Photo.ApertureValue", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.BrightnessValue":           {Name: "Exif.Photo.BrightnessValue", Types: []uint16{10}, Count: 1},
+	"Exif.Photo.ExposureBiasValue":         {Name: "Exif.Photo.ExposureBiasValue", Types: []uint16{10}, Count: 1},
+	"Exif.Photo.MaxApertureValue":          {Name: "Exif.Photo.MaxApertureValue", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.SubjectDistance":           {Name: "Exif.Photo.SubjectDistance", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.MeteringMode": {Name: "Exif.Photo.MeteringMode", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0":   "Unknown",
+		"1":   "Average",
+		"2":   "Center weighted average",
+		"3":   "Spot",
+		"4":   "Multi-spot",
+		"5":   "Pattern",
+		"6":   "Partial",
+		"255": "Other",
+	}},
+	"Exif.Photo.LightSource": {Name: "Exif.Photo.LightSource", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0":   "Unknown",
+		"1":   "Daylight",
+		"2":   "Fluorescent",
+		"3":   "Tungsten (incandescent light)",
+		"4":   "Flash",
+		"9":   "Fine weather",
+		"10":  "Cloudy weather",
+		"11":  "Shade",
+		"12":  "Daylight fluorescent (D 5700 - 7100K)",
+		"13":  "Day white fluorescent (N 4600 - 5500K)",
+		"14":  "Cool white fluorescent (W 3800 - 4500K)",
+		"15":  "White fluorescent (WW 3250 - 3800K)",
+		"16":  "Warm white fluorescent (L 2600 - 3250K)",
+		"17":  "Standard light A",
+		"18":  "Standard light B",
+		"19":  "Standard light C",
+		"20":  "D55",
+		"21":  "D65",
+		"22":  "D75",
+		"23":  "D50",
+		"24":  "ISO studio tungsten",
+		"255": "Other light source",
+	}},
+	"Exif.Photo.Flash": {Name: "Exif.Photo.Flash", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0":  "No flash",
+		"1":  "Fired",
+		"5":  "Fired, return light not detected",
+		"7":  "Fired, return light detected",
+		"8":  "On, did not fire",
+		"9":  "On, fired",
+		"13": "On, return light not detected",
+		"15": "On, return light detected",
+		"16": "Off, did not fire",
+		"20": "Off, did not fire, return light not detected",
+		"24": "Auto, did not fire",
+		"25": "Auto, fired",
+		"29": "Auto, fired, return light not detected",
+		"31": "Auto, fired, return light detected",
+		"32": "No flash function",
+		"48": "Off, no flash function",
+		"65": "Fired, red-eye reduction",
+		"69": "Fired, red-eye reduction, return light not detected",
+		"71": "Fired, red-eye reduction, return light detected",
+		"73": "On, red-eye reduction",
+		"77": "On, red-eye reduction, return light not detected",
+		"79": "On, red-eye reduction, return light detected",
+		"80": "Off, red-eye reduction",
+		"88": "Auto, did not fire, red-eye reduction",
+		"89": "Auto, fired, red-eye reduction",
+		"93": "Auto, fired, red-eye reduction, return light not detected",
+		"95": "Auto, fired, red-eye reduction, return light detected",
+	}},
+	"Exif.Photo.FocalLength":          {Name: "Exif.Photo.FocalLength", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.SubjectArea":          {Name: "Exif.Photo.SubjectArea", Types: []uint16{3}, Count: 0},
+	"Exif.Photo.MakerNote":            {Name: "Exif.Photo.MakerNote", Types: []uint16{7}, Count: 0},
+	"Exif.Photo.UserComment":          {Name: "Exif.Photo.UserComment", Types: []uint16{7}, Count: 0},
+	"Exif.Photo.SubSecTime":           {Name: "Exif.Photo.SubSecTime", Types: []uint16{2}, Count: 0},
+	"Exif.Photo.SubSecTimeOriginal":   {Name: "Exif.Photo.SubSecTimeOriginal", Types: []uint16{2}, Count: 0},
+	"Exif.Photo.SubSecTimeDigitized":  {Name: "Exif.Photo.SubSecTimeDigitized", Types: []uint16{2}, Count: 0},
+	"Exif.Photo.Temperature":          {Name: "Exif.Photo.Temperature", Types: []uint16{10}, Count: 1},
+	"Exif.Photo.Humidity":             {Name: "Exif.Photo.Humidity", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.Pressure":             {Name: "Exif.Photo.Pressure", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.WaterDepth":           {Name: "Exif.Photo.WaterDepth", Types: []uint16{10}, Count: 1},
+	"Exif.Photo.Acceleration":         {Name: "Exif.Photo.Acceleration", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.CameraElevationAngle": {Name: "Exif.Photo.CameraElevationAngle", Types: []uint16{10}, Count: 1},
+	"Exif.Photo.FlashpixVersion":      {Name: "Exif.Photo.FlashpixVersion", Types: []uint16{7}, Count: 4},
+	"Exif.Photo.ColorSpace": {Name: "Exif.Photo.ColorSpace", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1":     "sRGB",
+		"65535": "Uncalibrated",
+	}},
+	"Exif.Photo.PixelXDimension":          {Name: "Exif.Photo.PixelXDimension", Types: []uint16{3, 4}, Count: 1},
+	"Exif.Photo.PixelYDimension":          {Name: "Exif.Photo.PixelYDimension", Types: []uint16{3, 4}, Count: 1},
+	"Exif.Photo.RelatedSoundFile":         {Name: "Exif.Photo.RelatedSoundFile", Types: []uint16{2}, Count: 13},
+	"Exif.Photo.InteroperabilityTag":      {Name: "Exif.Photo.InteroperabilityTag", Types: []uint16{4}, Count: 1},
+	"Exif.Photo.FlashEnergy":              {Name: "Exif.Photo.FlashEnergy", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.SpatialFrequencyResponse": {Name: "Exif.Photo.SpatialFrequencyResponse", Types: []uint16{7}, Count: 0},
+	"Exif.Photo.FocalPlaneXResolution":    {Name: "Exif.Photo.FocalPlaneXResolution", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.FocalPlaneYResolution":    {Name: "Exif.Photo.FocalPlaneYResolution", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.FocalPlaneResolutionUnit": {Name: "Exif.Photo.FocalPlaneResolutionUnit", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "No absolute unit",
+		"2": "Inch",
+		"3": "Centimeter",
+	}},
+	"Exif.Photo.SubjectLocation": {Name: "Exif.Photo.SubjectLocation", Types: []uint16{3}, Count: 2},
+	"Exif.Photo.ExposureIndex":   {Name: "Exif.Photo.ExposureIndex", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.SensingMethod": {Name: "Exif.Photo.SensingMethod", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"1": "Not defined",
+		"2": "One-chip color area sensor",
+		"3": "Two-chip color area sensor",
+		"4": "Three-chip color area sensor",
+		"5": "Color sequential area sensor",
+		"7": "Trilinear sensor",
+		"8": "Color sequential linear sensor",
+	}},
+	"Exif.Photo.FileSource": {Name: "Exif.Photo.FileSource", Types: []uint16{7}, Count: 1, Values: map[string]string{
+		"0": "Others",
+		"1": "Scanner of transparent type",
+		"2": "Scanner of reflex type",
+		"3": "Digital still camera",
+	}},
+	"Exif.Photo.SceneType": {Name: "Exif.Photo.SceneType", Types: []uint16{7}, Count: 1, Values: map[string]string{
+		"1": "Directly photographed",
+	}},
+	"Exif.Photo.CFAPattern": {Name: "Exif.Photo.CFAPattern", Types: []uint16{7}, Count: 0},
+	"Exif.Photo.CustomRendered": {Name: "Exif.Photo.CustomRendered", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Normal process",
+		"1": "Custom process",
+	}},
+	"Exif.Photo.ExposureMode": {Name: "Exif.Photo.ExposureMode", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Auto",
+		"1": "Manual",
+		"2": "Auto bracket",
+	}},
+	"Exif.Photo.WhiteBalance": {Name: "Exif.Photo.WhiteBalance", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Auto",
+		"1": "Manual",
+	}},
+	"Exif.Photo.DigitalZoomRatio":      {Name: "Exif.Photo.DigitalZoomRatio", Types: []uint16{5}, Count: 1},
+	"Exif.Photo.FocalLengthIn35mmFilm": {Name: "Exif.Photo.FocalLengthIn35mmFilm", Types: []uint16{3}, Count: 1},
+	"Exif.Photo.SceneCaptureType": {Name: "Exif.Photo.SceneCaptureType", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Standard",
+		"1": "Landscape",
+		"2": "Portrait",
+		"3": "Night scene",
+	}},
+	"Exif.Photo.GainControl": {Name: "Exif.Photo.GainControl", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "None",
+		"1": "Low gain up",
+		"2": "High gain up",
+		"3": "Low gain down",
+		"4": "High gain down",
+	}},
+	"Exif.Photo.Contrast": {Name: "Exif.Photo.Contrast", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Normal",
+		"1": "Soft",
+		"2": "Hard",
+	}},
+	"Exif.Photo.Saturation": {Name: "Exif.Photo.Saturation", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Normal",
+		"1": "Low",
+		"2": "High",
+	}},
+	"Exif.Photo.Sharpness": {Name: "Exif.Photo.Sharpness", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Normal",
+		"1": "Soft",
+		"2": "Hard",
+	}},
+	"Exif.Photo.DeviceSettingDescription": {Name: "Exif.Photo.DeviceSettingDescription", Types: []uint16{7}, Count: 0},
+	"Exif.Photo.SubjectDistanceRange": {Name: "Exif.Photo.SubjectDistanceRange", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Unknown",
+		"1": "Macro",
+		"2": "Close view",
+		"3": "Distant view",
+	}},
+	"Exif.Photo.ImageUniqueID":     {Name: "Exif.Photo.ImageUniqueID", Types: []uint16{2}, Count: 33},
+	"Exif.Photo.CameraOwnerName":   {Name: "Exif.Photo.CameraOwnerName", Types: []uint16{2}, Count: 0},
+	"Exif.Photo.BodySerialNumber":  {Name: "Exif.Photo.BodySerialNumber", Types: []uint16{2}, Count: 0},
+	"Exif.Photo.LensSpecification": {Name: "Exif.Photo.LensSpecification", Types: []uint16{5}, Count: 4},
+	"Exif.Photo.LensMake":          {Name: "Exif.Photo.LensMake", Types: []uint16{2}, Count: 0},
+	"Exif.Photo.LensModel":         {Name: "Exif.Photo.LensModel", Types: []uint16{2}, Count: 0},
+	"Exif.Photo.LensSerialNumber":  {Name: "Exif.Photo.LensSerialNumber", Types: []uint16{2}, Count: 0},
+	"Exif.Photo.CompositeImage": {Name: "Exif.Photo.CompositeImage", Types: []uint16{3}, Count: 1, Values: map[string]string{
+		"0": "Unknown",
+		"1": "Not a composite image",
+		"2": "General composite image",
+		"3": "Composite image captured while shooting",
+	}},
+	"Exif.Photo.SourceImageNumberOfCompositeImage":   {Name: "Exif.Photo.SourceImageNumberOfCompositeImage", Types: []uint16{3}, Count: 2},
+	"Exif.Photo.SourceExposureTimesOfCompositeImage": {Name: "Exif.Photo.SourceExposureTimesOfCompositeImage", Types: []uint16{7}, Count: 0},
+	"Exif.Photo.Gamma":    {Name: "Exif.Photo.Gamma", Types: []uint16{5}, Count: 1},
+	"Exif.Image.CR2Slice": {Name: "Exif.Image.CR2Slice", Types: []uint16{3}, Count: 3},
+}
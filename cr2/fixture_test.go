@@ -0,0 +1,331 @@
+package cr2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/lpautet/cr2cv/ljpeg"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// Identifying values of the test file, all made up, for the scrubbing and
+// writing tests.
+const (
+	testSoftware       = "CR2 test"
+	testArtist         = "Test Artist"
+	testCopyright      = "(c) Test Artist"
+	testOwner          = "Test Owner"
+	testBodySerial     = "123456789012"
+	testLensSerial     = "0000000000"
+	testInternalSerial = "LX1234567"
+	testLensModel      = "EF50mm f/1.4 USM"
+)
+
+// Size of the raw image of the test file, and its slices.
+const (
+	testRawWidth   = 64
+	testRawHeight  = 40
+	testSliceCount = 2
+	testSliceSize  = 16
+)
+
+// testLatitude and testLongitude are the position of the GPS IFD, in
+// degrees, minutes and seconds.
+var (
+	testLatitude  = []Rational{{48, 1}, {51, 1}, {2950, 100}}
+	testLongitude = []Rational{{2, 1}, {17, 1}, {4000, 100}}
+)
+
+// testEntry is an IFD entry of the test file, with its value in little
+// endian order.
+type testEntry struct {
+	tag, tagType uint16
+	count        uint32
+	value        []byte
+}
+
+func le16(values ...uint16) []byte {
+	data := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint16(data[2*i:], v)
+	}
+	return data
+}
+
+func le32(values ...uint32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], v)
+	}
+	return data
+}
+
+func rationals(values ...Rational) []byte {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, values)
+	return data.Bytes()
+}
+
+// ascii returns s padded with NULs to n bytes.
+func ascii(s string, n int) []byte {
+	data := make([]byte, n)
+	copy(data, s)
+	return data
+}
+
+type testFile struct {
+	buf bytes.Buffer
+}
+
+func (f *testFile) align() {
+	if f.buf.Len()%2 == 1 {
+		f.buf.WriteByte(0)
+	}
+}
+
+// writeIFD writes an IFD followed by its out of line values. It returns the
+// offset of the IFD, the positions of the value fields of the entries and
+// the position of the next IFD offset.
+func (f *testFile) writeIFD(entries []testEntry) (int, map[uint16]int, int) {
+	f.align()
+	offset := f.buf.Len()
+	next := offset + 2 + 12*len(entries)
+	var values bytes.Buffer
+	fields := make(map[uint16]int)
+	f.buf.Write(le16(uint16(len(entries))))
+	for i, e := range entries {
+		f.buf.Write(le16(e.tag, e.tagType))
+		f.buf.Write(le32(e.count))
+		fields[e.tag] = offset + 2 + 12*i + 8
+		if len(e.value) <= 4 {
+			f.buf.Write(ascii(string(e.value), 4))
+			continue
+		}
+		if values.Len()%2 == 1 {
+			values.WriteByte(0)
+		}
+		f.buf.Write(le32(uint32(next + 4 + values.Len())))
+		values.Write(e.value)
+	}
+	f.buf.Write(le32(0))
+	f.buf.Write(values.Bytes())
+	return offset, fields, next
+}
+
+func (f *testFile) patch(at int, v uint32) {
+	binary.LittleEndian.PutUint32(f.buf.Bytes()[at:], v)
+}
+
+// appendData writes data word aligned and returns its offset.
+func (f *testFile) appendData(data []byte) uint32 {
+	f.align()
+	offset := f.buf.Len()
+	f.buf.Write(data)
+	return uint32(offset)
+}
+
+func testJPEG(t testing.TB, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 3), uint8(y * 5), 128, 255})
+		}
+	}
+	var data bytes.Buffer
+	if err := jpeg.Encode(&data, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return data.Bytes()
+}
+
+// testSensorValue is the sample at (x, y) of the raw image: a noisy black
+// border left of and above the active area, a saturated square and a smooth
+// RGGB scene elsewhere.
+func testSensorValue(x, y int) uint16 {
+	const black = 2048
+	if x < 8 || y < 4 {
+		return uint16(black + (x+y)%3 - 1)
+	}
+	if x >= 30 && x < 44 && y >= 10 && y < 24 {
+		if x%2 == 0 && y%2 == 0 {
+			return 12000
+		}
+		return 16383
+	}
+	if x%2 == 1 && y%2 == 1 {
+		return uint16(black + (x*17+y*29)%5000)
+	}
+	return uint16(black + (x*53+y*31)%9000)
+}
+
+// testRawStream returns the raw image as Canon stores it: a 14 bit lossless
+// JPEG frame of two components, the samples being laid out in vertical
+// slices.
+func testRawStream(t testing.TB) []byte {
+	lastSlice := testRawWidth - testSliceCount*testSliceSize
+	pix := make([]uint16, 0, testRawWidth*testRawHeight)
+	for s := 0; s <= testSliceCount; s++ {
+		width := testSliceSize
+		if s == testSliceCount {
+			width = lastSlice
+		}
+		for y := 0; y < testRawHeight; y++ {
+			for x := s * testSliceSize; x < s*testSliceSize+width; x++ {
+				pix = append(pix, testSensorValue(x, y))
+			}
+		}
+	}
+	img := &ljpeg.Image{
+		Precision:  14,
+		Width:      testRawWidth / 2,
+		Height:     testRawHeight,
+		Components: []ljpeg.Component{{ID: 1, H: 1, V: 1}, {ID: 2, H: 1, V: 1}},
+		Predictor:  1,
+		Pix:        pix,
+	}
+	var data bytes.Buffer
+	if err := ljpeg.Encode(&data, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return data.Bytes()
+}
+
+// buildTestFile returns a small Canon EOS 5D Mark III CR2 file: IFD#0 with
+// its EXIF, GPS and maker note sub-IFDs, a JPEG preview, a thumbnail, an RGB
+// image and a sliced raw image of testRawWidth x testRawHeight samples.
+// ASCII values are stored with and without trailing NULs.
+func buildTestFile(t testing.TB) []byte {
+	f := &testFile{}
+	f.buf.Write([]byte{'I', 'I', 0x2a, 0, 16, 0, 0, 0})
+	// the raw IFD offset is set once known
+	f.buf.Write([]byte{'C', 'R', 2, 0, 0, 0, 0, 0})
+
+	_, ifd0, ifd0Next := f.writeIFD([]testEntry{
+		{ExifImageWidth, TagTypeUint16, 1, le16(120)},
+		{ExifImageHeight, TagTypeUint16, 1, le16(80)},
+		{ExifImageMake, TagTypeString, 6, ascii("Canon", 6)},
+		{ExifImageModel, TagTypeString, 22, ascii("Canon EOS 5D Mark III", 22)},
+		{ExifImageStripOffset, TagTypeUint32, 1, le32(0)},
+		{ExifImageOrientation, TagTypeUint16, 1, le16(1)},
+		{ExifImageStripBytesCount, TagTypeUint32, 1, le32(0)},
+		// no trailing NUL
+		{testSoftwareTag, TagTypeString, 8, []byte(testSoftware)},
+		{0x0132, TagTypeString, 20, ascii("2016:04:08 13:14:15", 20)},
+		{ExifImageArtist, TagTypeString, 12, ascii(testArtist, 12)},
+		{ExifImageCopyright, TagTypeString, 16, ascii(testCopyright, 16)},
+		{ExifImageExifTag, TagTypeUint32, 1, le32(0)},
+		{ExifImageGPSTag, TagTypeUint32, 1, le32(0)},
+	})
+
+	gps, _, _ := f.writeIFD([]testEntry{
+		{0x0000, TagTypeUbyte, 4, []byte{2, 3, 0, 0}},
+		{ExifGPSLatitudeRef, TagTypeString, 2, ascii("N", 2)},
+		{ExifGPSLatitude, TagTypeUrational, 3, rationals(testLatitude...)},
+		{ExifGPSLongitudeRef, TagTypeString, 2, ascii("E", 2)},
+		{ExifGPSLongitude, TagTypeUrational, 3, rationals(testLongitude...)},
+	})
+	f.patch(ifd0[ExifImageGPSTag], uint32(gps))
+
+	exif, exifFields, _ := f.writeIFD([]testEntry{
+		{ExifPhotoExposureTime, TagTypeUrational, 1, rationals(Rational{1, 125})},
+		{ExifPhotoFNumber, TagTypeUrational, 1, rationals(Rational{56, 10})},
+		{ExifPhotoISOSpeedRatings, TagTypeUint16, 1, le16(400)},
+		{ExifPhotoDateTimeOriginal, TagTypeString, 20, ascii("2016:04:08 13:14:15", 20)},
+		{ExifPhotoExposureBiasValue, TagTypeRational, 1, le32(0xffffffff, 3)},
+		{ExifPhotoMeteringMode, TagTypeUint16, 1, le16(5)},
+		{ExifPhotoFocalLength, TagTypeUrational, 1, rationals(Rational{50, 1})},
+		// count and offset set once the maker note is written
+		{ExifPhotoMakerNote, TagTypeByteSequence, 0, le32(0)},
+		{ExifPhotoSubSecTimeOriginal, TagTypeString, 3, ascii("42", 3)},
+		{ExifPhotoBodySerialNumber, TagTypeString, 13, ascii(testBodySerial, 13)},
+		{ExifPhotoLensModel, TagTypeString, 21, ascii(testLensModel, 21)},
+		{ExifPhotoLensSerialNumber, TagTypeString, 11, ascii(testLensSerial, 11)},
+	})
+	f.patch(ifd0[ExifImageExifTag], uint32(exif))
+
+	cameraSettings := make([]uint16, 49)
+	cameraSettings[0] = 98
+	cameraSettings[1] = 2
+	cameraSettings[3] = 4
+	cameraSettings[22] = 2
+	cameraSettings[23] = 50
+	cameraSettings[24] = 50
+	cameraSettings[25] = 1
+	shotInfo := make([]uint16, 34)
+	shotInfo[0] = 68
+	shotInfo[2] = 160
+	// ColorData7, with the as shot and daylight records and the sRAW levels
+	colorData := make([]uint16, 1316)
+	colorData[0] = 10
+	copy(colorData[0x3f:], []uint16{2000, 1024, 1024, 1600, 5200})
+	copy(colorData[0x49:], []uint16{1100, 1170, 1170, 1250, 0})
+	copy(colorData[0x80:], []uint16{2100, 1024, 1024, 1500, 5500})
+	sensorInfo := make([]uint16, 17)
+	sensorInfo[0] = 34
+	sensorInfo[1] = testRawWidth
+	sensorInfo[2] = testRawHeight
+	// active area from (8, 4), the black border being left of and above it
+	sensorInfo[5] = 8
+	sensorInfo[6] = 4
+	sensorInfo[7] = testRawWidth - 1
+	sensorInfo[8] = testRawHeight - 1
+	makerNote, _, _ := f.writeIFD([]testEntry{
+		{ExifCanonCameraSettings, TagTypeUint16, uint32(len(cameraSettings)), le16(cameraSettings...)},
+		{ExifCanonShotInfo, TagTypeUint16, uint32(len(shotInfo)), le16(shotInfo...)},
+		{0x0006, TagTypeString, 24, ascii("Canon EOS 5D Mark III", 24)},
+		{ExifCanonFirmwareVersion, TagTypeString, 32, ascii("Firmware Version 1.2.3", 32)},
+		{ExifCanonOwnerName, TagTypeString, 32, ascii(testOwner, 32)},
+		{ExifCanonModelID, TagTypeUint32, 1, le32(0x80000285)},
+		{ExifCanonInternalSerialNumber, TagTypeString, 16, ascii(testInternalSerial, 16)},
+		{ExifCanonSensorInfo, TagTypeUint16, uint32(len(sensorInfo)), le16(sensorInfo...)},
+		{ExifCanonColorData, TagTypeUint16, uint32(len(colorData)), le16(colorData...)},
+	})
+	f.patch(exifFields[ExifPhotoMakerNote], uint32(makerNote))
+	f.patch(exifFields[ExifPhotoMakerNote]-4, uint32(f.buf.Len()-makerNote))
+
+	ifd1Offset, ifd1, ifd1Next := f.writeIFD([]testEntry{
+		{ExifImageThumbnailOffset, TagTypeUint32, 1, le32(0)},
+		{ExifImageThumbnailLength, TagTypeUint32, 1, le32(0)},
+	})
+	f.patch(ifd0Next, uint32(ifd1Offset))
+
+	const rgbWidth, rgbHeight = 30, 20
+	ifd2Offset, ifd2, ifd2Next := f.writeIFD([]testEntry{
+		{ExifImageWidth, TagTypeUint16, 1, le16(rgbWidth)},
+		{ExifImageHeight, TagTypeUint16, 1, le16(rgbHeight)},
+		{ExifImageStripOffset, TagTypeUint32, 1, le32(0)},
+	})
+	f.patch(ifd1Next, uint32(ifd2Offset))
+
+	lastSlice := testRawWidth - testSliceCount*testSliceSize
+	ifd3Offset, ifd3, _ := f.writeIFD([]testEntry{
+		{ExifImageWidth, TagTypeUint16, 1, le16(testRawWidth)},
+		{ExifImageHeight, TagTypeUint16, 1, le16(testRawHeight)},
+		{0x0103, TagTypeUint16, 1, le16(6)},
+		{ExifImageStripOffset, TagTypeUint32, 1, le32(0)},
+		{ExifImageStripBytesCount, TagTypeUint32, 1, le32(0)},
+		{ExifImageCR2Slice, TagTypeUint16, 3, le16(testSliceCount, testSliceSize, uint16(lastSlice))},
+	})
+	f.patch(ifd2Next, uint32(ifd3Offset))
+	f.patch(12, uint32(ifd3Offset))
+
+	preview := testJPEG(t, 120, 80)
+	f.patch(ifd0[ExifImageStripOffset], f.appendData(preview))
+	f.patch(ifd0[ExifImageStripBytesCount], uint32(len(preview)))
+	thumbnail := testJPEG(t, 16, 12)
+	f.patch(ifd1[ExifImageThumbnailOffset], f.appendData(thumbnail))
+	f.patch(ifd1[ExifImageThumbnailLength], uint32(len(thumbnail)))
+	var rgb []uint16
+	for y := 0; y < rgbHeight; y++ {
+		for x := 0; x < rgbWidth; x++ {
+			rgb = append(rgb, uint16(x*100), uint16(y*100), 2000)
+		}
+	}
+	f.patch(ifd2[ExifImageStripOffset], f.appendData(le16(rgb...)))
+	raw := testRawStream(t)
+	f.patch(ifd3[ExifImageStripOffset], f.appendData(raw))
+	f.patch(ifd3[ExifImageStripBytesCount], uint32(len(raw)))
+	return f.buf.Bytes()
+}
@@ -0,0 +1,155 @@
+//go:build ignore
+// +build ignore
+
+// gen_exiftags generates exiftags.go from the tag dictionary exiftags.txt.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var tagTypes = map[string]int{
+	"BYTE":      1,
+	"ASCII":     2,
+	"SHORT":     3,
+	"LONG":      4,
+	"RATIONAL":  5,
+	"SBYTE":     6,
+	"UNDEFINED": 7,
+	"SSHORT":    8,
+	"SLONG":     9,
+	"SRATIONAL": 10,
+	"FLOAT":     11,
+	"DOUBLE":    12,
+}
+
+// maps of tag names generated, by group
+var tables = []struct {
+	name   string
+	groups []string
+}{
+	{"KnownExifTags", []string{"Image", "Photo"}},
+	{"KnownGPSTags", []string{"GPSInfo"}},
+	{"KnownInteropTags", []string{"Iop"}},
+}
+
+type tag struct {
+	group  string
+	id     uint16
+	name   string
+	types  []int
+	count  int
+	values [][2]string
+}
+
+func main() {
+	fp, err := os.Open("exiftags.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer fp.Close()
+
+	var tags []tag
+	scanner := bufio.NewScanner(fp)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		t, err := parseTag(strings.Split(text, "\t"))
+		if err != nil {
+			log.Fatalf("exiftags.txt:%d: %v", line, err)
+		}
+		tags = append(tags, t)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].id < tags[j].id })
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by go run gen_exiftags.go; DO NOT EDIT.\n\npackage cr2\n")
+	for _, table := range tables {
+		fmt.Fprintf(&out, "\nvar %s = map[uint16]string{\n", table.name)
+		for _, t := range tags {
+			for _, group := range table.groups {
+				if t.group == group {
+					fmt.Fprintf(&out, "0x%04x: %q,\n", t.id, "Exif."+t.group+"."+t.name)
+				}
+			}
+		}
+		fmt.Fprintf(&out, "}\n")
+	}
+	fmt.Fprintf(&out, "\nvar tagInfos = map[string]*TagInfo{\n")
+	for _, t := range tags {
+		name := "Exif." + t.group + "." + t.name
+		fmt.Fprintf(&out, "%q: {Name: %q, Types: []uint16{", name, name)
+		for i, tagType := range t.types {
+			if i > 0 {
+				fmt.Fprintf(&out, ", ")
+			}
+			fmt.Fprintf(&out, "%d", tagType)
+		}
+		fmt.Fprintf(&out, "}, Count: %d", t.count)
+		if len(t.values) > 0 {
+			fmt.Fprintf(&out, ", Values: map[string]string{\n")
+			for _, v := range t.values {
+				fmt.Fprintf(&out, "%q: %q,\n", v[0], v[1])
+			}
+			fmt.Fprintf(&out, "}")
+		}
+		fmt.Fprintf(&out, "},\n")
+	}
+	fmt.Fprintf(&out, "}\n")
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("exiftags.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseTag(fields []string) (tag, error) {
+	if len(fields) < 5 || len(fields) > 6 {
+		return tag{}, fmt.Errorf("expected 5 or 6 fields, got %d", len(fields))
+	}
+	t := tag{group: fields[0], name: fields[2]}
+	id, err := strconv.ParseUint(fields[1], 0, 16)
+	if err != nil {
+		return tag{}, err
+	}
+	t.id = uint16(id)
+	for _, name := range strings.Split(fields[3], ",") {
+		tagType, ok := tagTypes[name]
+		if !ok {
+			return tag{}, fmt.Errorf("unknown type %s", name)
+		}
+		t.types = append(t.types, tagType)
+	}
+	if fields[4] != "-" {
+		if t.count, err = strconv.Atoi(fields[4]); err != nil {
+			return tag{}, err
+		}
+	}
+	if len(fields) == 6 {
+		for _, value := range strings.Split(fields[5], ";") {
+			kv := strings.SplitN(value, "=", 2)
+			if len(kv) != 2 {
+				return tag{}, fmt.Errorf("invalid value %q", value)
+			}
+			t.values = append(t.values, [2]string{kv[0], kv[1]})
+		}
+	}
+	return t, nil
+}
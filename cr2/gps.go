@@ -0,0 +1,77 @@
+package cr2
+
+import (
+	"strings"
+	"time"
+)
+
+// GPS is the position recorded in the GPS sub-IFD.
+type GPS struct {
+	// Latitude and Longitude in decimal degrees, negative to the south and
+	// to the west
+	Latitude  float64
+	Longitude float64
+	// Altitude in meters, negative below sea level
+	Altitude    float64
+	HasAltitude bool
+	// Time in UTC, zero when not recorded
+	Time time.Time
+	// ImgDirection in degrees, relative to the true north, or to the magnetic
+	// north when ImgDirectionRef is "M"
+	ImgDirection    float64
+	ImgDirectionRef string
+	HasImgDirection bool
+	Satellites      string
+	MapDatum        string
+}
+
+// GPS returns the position recorded in the file, or ErrNoGPS.
+func (cf *CR2File) GPS() (*GPS, error) {
+	ifd := &cf.gpsSubIfd
+	if cf.ifd0.TagsById[ExifImageGPSTag] == nil || ifd.TagsById[ExifGPSLatitude] == nil || ifd.TagsById[ExifGPSLongitude] == nil {
+		return nil, ErrNoGPS
+	}
+	g := &GPS{
+		Latitude:        degrees(ifd.rationalsTag(ExifGPSLatitude)),
+		Longitude:       degrees(ifd.rationalsTag(ExifGPSLongitude)),
+		ImgDirectionRef: ifd.stringTag(ExifGPSImgDirectionRef),
+		Satellites:      ifd.stringTag(ExifGPSSatellites),
+		MapDatum:        ifd.stringTag(ExifGPSMapDatum),
+	}
+	if strings.HasPrefix(ifd.stringTag(ExifGPSLatitudeRef), "S") {
+		g.Latitude = -g.Latitude
+	}
+	if strings.HasPrefix(ifd.stringTag(ExifGPSLongitudeRef), "W") {
+		g.Longitude = -g.Longitude
+	}
+	if ifd.TagsById[ExifGPSAltitude] != nil {
+		g.HasAltitude = true
+		g.Altitude = ifd.rationalTag(ExifGPSAltitude).Float()
+		if ref := ifd.TagsById[ExifGPSAltitudeRef]; ref != nil && ref.TagType == TagTypeUbyte && ref.DataOrOffset&0xff == 1 {
+			g.Altitude = -g.Altitude
+		}
+	}
+	if ifd.TagsById[ExifGPSImgDirection] != nil {
+		g.HasImgDirection = true
+		g.ImgDirection = ifd.rationalTag(ExifGPSImgDirection).Float()
+	}
+	if date, err := time.Parse("2006:01:02", ifd.stringTag(ExifGPSDateStamp)); err == nil {
+		g.Time = date
+		if stamp := ifd.rationalsTag(ExifGPSTimeStamp); len(stamp) == 3 {
+			seconds := degrees(stamp) * 3600
+			g.Time = date.Add(time.Duration(seconds * float64(time.Second)))
+		}
+	}
+	return g, nil
+}
+
+// degrees returns the decimal value of degrees, minutes and seconds.
+func degrees(dms []Rational) float64 {
+	value := 0.0
+	scale := 1.0
+	for _, r := range dms {
+		value += r.Float() / scale
+		scale *= 60
+	}
+	return value
+}
@@ -0,0 +1,205 @@
+package cr2
+
+import (
+	"fmt"
+	"github.com/lpautet/cr2cv/demosaic"
+	"github.com/lpautet/cr2cv/internal/imageutil"
+	"math"
+)
+
+// HighlightMode selects how pixels with saturated channels are rendered.
+type HighlightMode int
+
+const (
+	// HighlightClip clips every channel to the lowest saturation level,
+	// giving white highlights
+	HighlightClip HighlightMode = iota
+	// HighlightBlend keeps the lightness of the unclipped values with the
+	// chroma of the clipped ones
+	HighlightBlend
+	// HighlightReconstruct rebuilds saturated channels from the unclipped
+	// ones, using the colour ratios of the neighbourhood
+	HighlightReconstruct
+)
+
+func (m HighlightMode) String() string {
+	switch m {
+	case HighlightClip:
+		return "clip"
+	case HighlightBlend:
+		return "blend"
+	case HighlightReconstruct:
+		return "reconstruct"
+	}
+	return fmt.Sprintf("HighlightMode(%d)", int(m))
+}
+
+func ParseHighlightMode(name string) (HighlightMode, error) {
+	for _, m := range []HighlightMode{HighlightClip, HighlightBlend, HighlightReconstruct} {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("cr2: unknown highlight mode %q", name)
+}
+
+// Radius of the neighbourhood searched for colour ratios by
+// HighlightReconstruct
+const highlightRadius = 4
+
+// recoverHighlights processes the white balanced camera RGB image, clip being
+// the level each channel saturates at.
+func recoverHighlights(img *demosaic.RGB, clip [3]float32, mode HighlightMode, workers int) error {
+	switch mode {
+	case HighlightClip:
+		clipHighlights(img, clip, workers)
+	case HighlightBlend:
+		blendHighlights(img, clip, workers)
+	case HighlightReconstruct:
+		reconstructHighlights(img, clip, workers)
+	default:
+		return fmt.Errorf("cr2: unknown highlight mode %v", mode)
+	}
+	return nil
+}
+
+func minOf(v [3]float32) float32 {
+	return float32(math.Min(float64(v[0]), math.Min(float64(v[1]), float64(v[2]))))
+}
+
+func maxOf(v [3]float32) float32 {
+	return float32(math.Max(float64(v[0]), math.Max(float64(v[1]), float64(v[2]))))
+}
+
+func clipHighlights(img *demosaic.RGB, clip [3]float32, workers int) {
+	level := minOf(clip)
+	width := img.Rect.Dx()
+	imageutil.ParallelFor(img.Rect.Dy(), workers, func(y int) {
+		row := img.Pix[y*img.Stride : y*img.Stride+3*width]
+		for i, v := range row {
+			if v > level {
+				row[i] = level
+			}
+		}
+	})
+}
+
+// Opponent colour transform used to blend highlights, lightness first
+var (
+	blendTrans = [3][3]float32{
+		{1, 1, 1},
+		{1.7320508, -1.7320508, 0},
+		{-1, -1, 2},
+	}
+	blendITrans = [3][3]float32{
+		{1, 0.8660254, -0.5},
+		{1, -0.8660254, -0.5},
+		{1, 0, 1},
+	}
+)
+
+func blendHighlights(img *demosaic.RGB, clip [3]float32, workers int) {
+	level := minOf(clip)
+	width := img.Rect.Dx()
+	imageutil.ParallelFor(img.Rect.Dy(), workers, func(y int) {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			p := row[3*x : 3*x+3]
+			if p[0] <= level && p[1] <= level && p[2] <= level {
+				continue
+			}
+			var cam [2][3]float32
+			var lab [2][3]float32
+			for c := 0; c < 3; c++ {
+				cam[0][c] = p[c]
+				cam[1][c] = p[c]
+				if cam[1][c] > level {
+					cam[1][c] = level
+				}
+			}
+			for i := 0; i < 2; i++ {
+				for k := 0; k < 3; k++ {
+					lab[i][k] = blendTrans[k][0]*cam[i][0] + blendTrans[k][1]*cam[i][1] + blendTrans[k][2]*cam[i][2]
+				}
+			}
+			sum := lab[0][1]*lab[0][1] + lab[0][2]*lab[0][2]
+			ratio := float32(1)
+			if sum > 0 {
+				ratio = float32(math.Sqrt(float64((lab[1][1]*lab[1][1] + lab[1][2]*lab[1][2]) / sum)))
+			}
+			lab[0][1] *= ratio
+			lab[0][2] *= ratio
+			for c := 0; c < 3; c++ {
+				v := (blendITrans[c][0]*lab[0][0] + blendITrans[c][1]*lab[0][1] + blendITrans[c][2]*lab[0][2]) / 3
+				if v > level {
+					v = level
+				}
+				p[c] = v
+			}
+		}
+	})
+}
+
+func reconstructHighlights(img *demosaic.RGB, clip [3]float32, workers int) {
+	src := make([]float32, len(img.Pix))
+	copy(src, img.Pix)
+	width := img.Rect.Dx()
+	height := img.Rect.Dy()
+	clipped := func(i int, c int) bool {
+		return src[i+c] >= clip[c]
+	}
+	imageutil.ParallelFor(height, workers, func(y int) {
+		for x := 0; x < width; x++ {
+			i := y*img.Stride + 3*x
+			// brightest unclipped channel
+			ref := -1
+			n := 0
+			for c := 0; c < 3; c++ {
+				if clipped(i, c) {
+					n++
+				} else if ref < 0 || src[i+c] > src[i+ref] {
+					ref = c
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			if ref < 0 {
+				// nothing left to rebuild from: neutral at the highest level
+				m := maxOf([3]float32{src[i], src[i+1], src[i+2]})
+				img.Pix[i], img.Pix[i+1], img.Pix[i+2] = m, m, m
+				continue
+			}
+			for c := 0; c < 3; c++ {
+				if !clipped(i, c) {
+					continue
+				}
+				var ratio float32
+				count := 0
+				for yy := y - highlightRadius; yy <= y+highlightRadius; yy++ {
+					if yy < 0 || yy >= height {
+						continue
+					}
+					for xx := x - highlightRadius; xx <= x+highlightRadius; xx++ {
+						if xx < 0 || xx >= width {
+							continue
+						}
+						j := yy*img.Stride + 3*xx
+						if clipped(j, c) || clipped(j, ref) || src[j+ref] <= 0 {
+							continue
+						}
+						ratio += src[j+c] / src[j+ref]
+						count++
+					}
+				}
+				estimate := src[i+ref]
+				if count > 0 {
+					estimate *= ratio / float32(count)
+				}
+				if estimate > img.Pix[i+c] {
+					img.Pix[i+c] = estimate
+				}
+			}
+		}
+	})
+}
@@ -1,12 +1,15 @@
 package cr2
 
 import (
+	"encoding/binary"
 	"fmt"
 	"github.com/lpautet/cr2cv/bufreader"
+	"strings"
 )
 
 type ImageFileDirectory struct {
 	Name            string
+	Offset          int64
 	NumberOfEntries uint16
 	Entries         []IFDEntry
 	NextIFDOffset   uint32
@@ -16,6 +19,8 @@ type ImageFileDirectory struct {
 
 	ParentFile *CR2File
 	resolver   TagNameResolver
+	// number of entries the table had in the file, 0 for a new IFD
+	capacity int
 }
 
 func (ifd *ImageFileDirectory) Init(name string, file *CR2File, resolver TagNameResolver) {
@@ -44,6 +49,22 @@ func GetCanonTagName(tagId uint16) string {
 	return fmt.Sprintf("Exif.Canon.Tag-0x%x", tagId)
 }
 
+func GetGPSTagName(tagId uint16) string {
+	ret := KnownGPSTags[tagId]
+	if ret != "" {
+		return ret
+	}
+	return fmt.Sprintf("Exif.GPSInfo.Tag-0x%x", tagId)
+}
+
+func GetInteropTagName(tagId uint16) string {
+	ret := KnownInteropTags[tagId]
+	if ret != "" {
+		return ret
+	}
+	return fmt.Sprintf("Exif.Iop.Tag-0x%x", tagId)
+}
+
 type IFDEntry struct {
 	TagID          uint16
 	TagType        uint16
@@ -66,6 +87,11 @@ func (e *IFDEntry) StringValue(file *CR2File) string {
 	if e.TagType != TagTypeString {
 		panic("Requesting string from an invalid entry type")
 	}
+	if e.NumberOfValues <= 4 {
+		inline := make([]byte, 4)
+		binary.LittleEndian.PutUint32(inline, e.DataOrOffset)
+		return strings.TrimRight(string(inline[:e.NumberOfValues]), "\x00")
+	}
 	if e.DataOrOffset == 0 {
 		return ""
 	}
@@ -73,7 +99,9 @@ func (e *IFDEntry) StringValue(file *CR2File) string {
 	if value == nil {
 		return fmt.Sprintf("<%d offset not found>", e.DataOrOffset)
 	}
-	return value.(string)
+	// "" when another entry at the same offset has a different type
+	text, _ := value.(string)
+	return strings.TrimRight(text, "\x00")
 }
 
 func (e *IFDEntry) Uint16Value() uint16 {
@@ -103,11 +131,8 @@ func (e *IFDEntry) ByteArrayValue(file *CR2File) []byte {
 	if e.NumberOfValues < 4 {
 		panic("Requesting too small byte sequence")
 	}
-	value := file.ValuesByOffset[e.DataOrOffset]
-	if value == nil {
-		return nil
-	}
-	return value.([]byte)
+	value, _ := file.ValuesByOffset[e.DataOrOffset].([]byte)
+	return value
 }
 
 func (e *IFDEntry) Uint8ArrayValue(file *CR2File) []uint8 {
@@ -117,11 +142,8 @@ func (e *IFDEntry) Uint8ArrayValue(file *CR2File) []uint8 {
 	if e.NumberOfValues <= 4 {
 		panic("Requesting too small uint8 sequence")
 	}
-	value := file.ValuesByOffset[e.DataOrOffset]
-	if value == nil {
-		return nil
-	}
-	return value.([]uint8)
+	value, _ := file.ValuesByOffset[e.DataOrOffset].([]uint8)
+	return value
 }
 
 func (e *IFDEntry) Uint16ArrayValue(file *CR2File) []uint16 {
@@ -131,8 +153,8 @@ func (e *IFDEntry) Uint16ArrayValue(file *CR2File) []uint16 {
 	if e.NumberOfValues <= 2 {
 		panic("Requesting too small uint16 sequence")
 	}
-	value := file.ValuesByOffset[e.DataOrOffset]
-	return value.([]uint16)
+	value, _ := file.ValuesByOffset[e.DataOrOffset].([]uint16)
+	return value
 }
 
 func (e *IFDEntry) Uint32ArrayValue(file *CR2File) []uint32 {
@@ -142,8 +164,8 @@ func (e *IFDEntry) Uint32ArrayValue(file *CR2File) []uint32 {
 	if e.NumberOfValues <= 1 {
 		panic("Requesting too small uint32 sequence")
 	}
-	value := file.ValuesByOffset[e.DataOrOffset]
-	return value.([]uint32)
+	value, _ := file.ValuesByOffset[e.DataOrOffset].([]uint32)
+	return value
 }
 
 func (e *IFDEntry) Value(file *CR2File) interface{} {
@@ -168,20 +190,19 @@ func (e *IFDEntry) Value(file *CR2File) interface{} {
 	case TagTypeUrational:
 		return file.ValuesByOffset[e.DataOrOffset]
 	case TagTypeByteSequence:
+		if e.NumberOfValues <= 4 {
+			return e.DataOrOffset
+		}
 		return e.ByteArrayValue(file)
 	case TagTypeRational:
-		value := file.ValuesByOffset[e.DataOrOffset]
-		if e.NumberOfValues == 1 {
-			return value.(SRational)
-		}
-		panic("Not implemented")
+		return file.ValuesByOffset[e.DataOrOffset]
 	default:
-		panic("Not implemented")
+		return e.DataOrOffset
 	}
 }
 
-func (ife *IFDEntry) ReadFrom(fr *bufreader.BufferReader) {
-	fr.ReadInto(12, ife)
+func (ife *IFDEntry) ReadFrom(fr *bufreader.BufferReader) error {
+	return fr.ReadInto(12, ife)
 }
 
 const TagTypeUbyte = 0x01
@@ -192,13 +213,42 @@ const TagTypeUrational = 0x05
 const TagTypeByteSequence = 0x07
 const TagTypeRational = 0x0a
 
-func (ifd *ImageFileDirectory) readFrom(reader *bufreader.BufferReader) {
+// tagTypeSize returns the size in bytes of a value of a tag type, 0 for
+// unknown types.
+func tagTypeSize(tagType uint16) int {
+	switch tagType {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	}
+	return 0
+}
 
-	ifd.NumberOfEntries = reader.ReadUint16()
+// outOfLine reports whether the value of the entry is stored at DataOrOffset
+// rather than in it.
+func (e *IFDEntry) outOfLine() bool {
+	return int64(e.NumberOfValues)*int64(tagTypeSize(e.TagType)) > 4
+}
+
+func (ifd *ImageFileDirectory) readFrom(reader *bufreader.BufferReader) error {
+
+	ifd.Offset = reader.Offset
+	numberOfEntries, err := reader.ReadUint16()
+	if err != nil {
+		return wrapError(reader.Offset, ifd.Name, err)
+	}
+	ifd.NumberOfEntries = numberOfEntries
+	ifd.capacity = int(numberOfEntries)
 
-	fmt.Printf("%s: Number of entries: %d\n", ifd.Name, ifd.NumberOfEntries)
 	ifd.Entries = make([]IFDEntry, ifd.NumberOfEntries)
-	reader.ReadInto(int64(12*ifd.NumberOfEntries), &ifd.Entries)
+	if err := reader.ReadInto(12*int64(ifd.NumberOfEntries), &ifd.Entries); err != nil {
+		return wrapError(reader.Offset, ifd.Name, err)
+	}
 
 	for i, entry := range ifd.Entries {
 		pEntry := &(ifd.Entries[i])
@@ -209,11 +259,11 @@ func (ifd *ImageFileDirectory) readFrom(reader *bufreader.BufferReader) {
 		//fmt.Printf("Extracting %s@%d (n=%d, type=%d)\n", extractor.name, extractor.entry.DataOrOffset, extractor.entry.NumberOfValues, extractor.entry.TagType)
 		switch entry.TagType {
 		case TagTypeString:
-			if entry.DataOrOffset != 0 {
+			if entry.NumberOfValues > 4 && entry.DataOrOffset != 0 {
 				ifd.ParentFile.AddValueToExtract(pEntry)
 			}
 		case TagTypeUint16:
-			if entry.NumberOfValues != 1 {
+			if entry.NumberOfValues > 2 {
 				ifd.ParentFile.AddValueToExtract(pEntry)
 			}
 		case TagTypeUint32:
@@ -233,17 +283,52 @@ func (ifd *ImageFileDirectory) readFrom(reader *bufreader.BufferReader) {
 		case TagTypeRational:
 			ifd.ParentFile.AddValueToExtract(pEntry)
 		default:
-			fmt.Printf("Unknown TagType in %s for entry %s: %d\n", ifd.Name, KnownExifTags[entry.TagID], entry.TagType)
+			// values of other types are not extracted
 			continue
 		}
 	}
-	ifd.NextIFDOffset = reader.ReadUint32()
+	nextIFDOffset, err := reader.ReadUint32()
+	if err != nil {
+		return wrapError(reader.Offset, ifd.Name, err)
+	}
+	ifd.NextIFDOffset = nextIFDOffset
+	return nil
+}
+
+func (ifd *ImageFileDirectory) uintTag(tagId uint16) (uint32, error) {
+	entry := ifd.TagsById[tagId]
+	if entry == nil {
+		return 0, formatErrorf(ifd.Offset, ifd.Name, "missing tag %s", ifd.resolver(tagId))
+	}
+	if entry.NumberOfValues != 1 || (entry.TagType != TagTypeUint16 && entry.TagType != TagTypeUint32) {
+		return 0, formatErrorf(ifd.Offset, ifd.Name, "unexpected type %d/count %d for tag %s", entry.TagType, entry.NumberOfValues, ifd.resolver(tagId))
+	}
+	if entry.TagType == TagTypeUint16 {
+		return uint32(uint16(entry.DataOrOffset)), nil
+	}
+	return entry.DataOrOffset, nil
+}
+
+// uint16ArrayTag returns the values of a SHORT tag stored out of the entry.
+func (ifd *ImageFileDirectory) uint16ArrayTag(tagId uint16) ([]uint16, error) {
+	entry := ifd.TagsById[tagId]
+	if entry == nil {
+		return nil, formatErrorf(ifd.Offset, ifd.Name, "missing tag %s", ifd.resolver(tagId))
+	}
+	if entry.TagType != TagTypeUint16 || entry.NumberOfValues <= 2 {
+		return nil, formatErrorf(ifd.Offset, ifd.Name, "unexpected type %d/count %d for tag %s", entry.TagType, entry.NumberOfValues, ifd.resolver(tagId))
+	}
+	values := entry.Uint16ArrayValue(ifd.ParentFile)
+	if values == nil {
+		return nil, formatErrorf(int64(entry.DataOrOffset), ifd.Name, "value of %s not found", ifd.resolver(tagId))
+	}
+	return values, nil
 }
 
-func (ifd *ImageFileDirectory) dumpTags(file *CR2File) {
+func (ifd *ImageFileDirectory) dumpTags() {
 	fmt.Printf("%s:\n", ifd.Name)
-	for tagName, tagEntry := range ifd.TagsByName {
-		tagValue := tagEntry.Value(file)
-		fmt.Printf("\t%s: :%v\n", tagName, tagValue)
+	for i := range ifd.Entries {
+		entry := &ifd.Entries[i]
+		fmt.Printf("\t%s: %s\n", ifd.resolver(entry.TagID), ifd.formatValue(entry))
 	}
 }
@@ -0,0 +1,45 @@
+package cr2
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Entries of different types may share an offset in malformed files: the
+// value stored is the one of the first entry extracted.
+func TestValueOfSharedOffset(t *testing.T) {
+	cf := &CR2File{}
+	cf.Init()
+	cf.ValuesByOffset[100] = []uint16{1, 2, 3}
+	cf.ValuesByOffset[200] = "text"
+
+	text := IFDEntry{TagType: TagTypeString, NumberOfValues: 6, DataOrOffset: 100}
+	if v := text.StringValue(cf); v != "" {
+		t.Errorf("StringValue = %q, want \"\"", v)
+	}
+	bytes := IFDEntry{TagType: TagTypeByteSequence, NumberOfValues: 6, DataOrOffset: 200}
+	if v := bytes.ByteArrayValue(cf); v != nil {
+		t.Errorf("ByteArrayValue = %v, want nil", v)
+	}
+	uint8s := IFDEntry{TagType: TagTypeUbyte, NumberOfValues: 6, DataOrOffset: 200}
+	if v := uint8s.Uint8ArrayValue(cf); v != nil {
+		t.Errorf("Uint8ArrayValue = %v, want nil", v)
+	}
+	uint16s := IFDEntry{TagType: TagTypeUint16, NumberOfValues: 3, DataOrOffset: 200}
+	if v := uint16s.Uint16ArrayValue(cf); v != nil {
+		t.Errorf("Uint16ArrayValue = %v, want nil", v)
+	}
+
+	ifd := &ImageFileDirectory{}
+	ifd.Init("IFD", cf, GetExifTagName)
+	ifd.Entries = []IFDEntry{text, bytes, uint8s, uint16s}
+	for i, want := range []string{"", "[]", "[]", "[]"} {
+		entry := &ifd.Entries[i]
+		if v := ifd.formatValue(entry); v != want {
+			t.Errorf("formatValue of type %d = %q, want %q", entry.TagType, v, want)
+		}
+		if v := fmt.Sprint(entry.Value(cf)); v != want {
+			t.Errorf("Value of type %d = %q, want %q", entry.TagType, v, want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cr2
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Lens identifies the lens a file was taken with, zero fields being unknown.
+type Lens struct {
+	Name string
+	// ID is the Canon lens type
+	ID int
+	// MinFocalLength and MaxFocalLength in millimeters
+	MinFocalLength float64
+	MaxFocalLength float64
+	// MaxAperture is the largest aperture, as an f-number
+	MaxAperture float64
+	Serial      string
+}
+
+type canonLens struct {
+	id   int
+	name string
+}
+
+// Lens resolves the lens from the EXIF lens tags, the Canon lens model and
+// lens info, and the lens type of CameraSettings looked up in a table of
+// Canon lens types.
+func (cf *CR2File) Lens() *Lens {
+	exif, maker := &cf.exifSubIfd, &cf.makerNodeSubIfd
+	l := &Lens{Serial: exif.stringTag(ExifPhotoLensSerialNumber)}
+	model := exif.stringTag(ExifPhotoLensModel)
+	if model == "" {
+		model = maker.stringTag(ExifCanonLensModel)
+	}
+	if spec := exif.rationalsTag(ExifPhotoLensSpecification); len(spec) == 4 {
+		l.MinFocalLength, l.MaxFocalLength, l.MaxAperture = spec[0].Float(), spec[1].Float(), spec[2].Float()
+	}
+	if cs, err := cf.CameraSettings(); err == nil {
+		// 0xffff when no lens type is recorded
+		if cs.LensType != 0xffff {
+			l.ID = cs.LensType
+		}
+		if cs.MaxFocalLength > 0 {
+			l.MinFocalLength, l.MaxFocalLength = cs.MinFocalLength, cs.MaxFocalLength
+		}
+		if cs.MaxAperture > 0 {
+			l.MaxAperture = cs.MaxAperture
+		}
+	}
+	l.Name = lookupCanonLens(l.ID, l.MinFocalLength, l.MaxFocalLength, model)
+	if l.Name == "" {
+		l.Name = model
+	}
+
+	// the first bytes of LensInfo are the serial number, in hexadecimal
+	if strings.Trim(l.Serial, "0") == "" {
+		l.Serial = ""
+		if info := maker.bytesTag(ExifCanonLensInfo); len(info) >= 5 && strings.Trim(string(info[:5]), "\x00") != "" {
+			l.Serial = fmt.Sprintf("%x", info[:5])
+		}
+	}
+	return l
+}
+
+// lookupCanonLens returns the name of a Canon lens type, among the lenses of
+// that type with the focal range given, when known. The lens model reported
+// by the camera picks among them, and is trusted over the table when it
+// matches none: "" is returned then.
+func lookupCanonLens(id int, minFocal float64, maxFocal float64, model string) string {
+	var candidates []string
+	for _, lens := range canonLenses {
+		if lens.id != id {
+			continue
+		}
+		if minFocal > 0 {
+			if lensMin, lensMax, ok := focalRange(lens.name); ok && (lensMin != minFocal || lensMax != maxFocal) {
+				continue
+			}
+		}
+		candidates = append(candidates, lens.name)
+	}
+	if model == "" {
+		if len(candidates) > 0 {
+			return candidates[0]
+		}
+		return ""
+	}
+	for _, name := range candidates {
+		if strings.Contains(normalizeLensName(name), normalizeLensName(model)) {
+			return name
+		}
+	}
+	return ""
+}
+
+var focalRangeRegexp = regexp.MustCompile(`(\d+(?:\.\d+)?)(?:-(\d+(?:\.\d+)?))?mm`)
+
+// focalRange returns the focal lengths in the name of a lens.
+func focalRange(name string) (float64, float64, bool) {
+	match := focalRangeRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return 0, 0, false
+	}
+	min, _ := strconv.ParseFloat(match[1], 64)
+	max := min
+	if match[2] != "" {
+		max, _ = strconv.ParseFloat(match[2], 64)
+	}
+	return min, max, true
+}
+
+// normalizeLensName drops the spaces and the brand of a lens name, which
+// cameras write as "EF50mm f/1.4 USM".
+func normalizeLensName(name string) string {
+	name = strings.ToLower(strings.Replace(name, " ", "", -1))
+	return strings.TrimPrefix(name, "canon")
+}
+
+// bytesTag returns the value of a BYTE or UNDEFINED tag, nil when missing.
+func (ifd *ImageFileDirectory) bytesTag(tagID uint16) []byte {
+	entry := ifd.TagsById[tagID]
+	if entry == nil || (entry.TagType != TagTypeUbyte && entry.TagType != TagTypeByteSequence) {
+		return nil
+	}
+	if !entry.outOfLine() {
+		return entry.inlineBytes()
+	}
+	value, _ := ifd.ParentFile.ValuesByOffset[entry.DataOrOffset].([]byte)
+	return value
+}
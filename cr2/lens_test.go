@@ -0,0 +1,34 @@
+package cr2
+
+import "testing"
+
+func TestLookupCanonLens(t *testing.T) {
+	tests := []struct {
+		id                 int
+		minFocal, maxFocal float64
+		model              string
+		want               string
+	}{
+		// lens type 6 is shared by a Canon, a Sigma and a Tokina lens
+		{6, 0, 0, "", "Canon EF 28-70mm f/3.5-4.5"},
+		{6, 18, 50, "", "Sigma 18-50mm f/3.5-5.6 DC"},
+		{6, 19, 35, "", "Tokina AF 193-2 19-35mm f/3.5-4.5"},
+		{6, 28, 70, "", "Canon EF 28-70mm f/3.5-4.5"},
+		// the model picks among the lenses of the focal range, ignoring
+		// spaces, case and the Canon prefix
+		{6, 0, 0, "Sigma 18-50mm f/3.5-5.6 DC", "Sigma 18-50mm f/3.5-5.6 DC"},
+		{6, 0, 0, "EF28-70mm f/3.5-4.5", "Canon EF 28-70mm f/3.5-4.5"},
+		{6, 19, 35, "tokina af 193-2", "Tokina AF 193-2 19-35mm f/3.5-4.5"},
+		// the model matching none of them is trusted over the table
+		{6, 18, 50, "Tokina AF 193-2 19-35mm f/3.5-4.5", ""},
+		{6, 0, 0, "Tamron 28-75mm", ""},
+		// no lens of that focal range or type
+		{6, 24, 105, "", ""},
+		{-2, 0, 0, "", ""},
+	}
+	for _, test := range tests {
+		if got := lookupCanonLens(test.id, test.minFocal, test.maxFocal, test.model); got != test.want {
+			t.Errorf("lookupCanonLens(%d, %v, %v, %q) = %q, want %q", test.id, test.minFocal, test.maxFocal, test.model, got, test.want)
+		}
+	}
+}
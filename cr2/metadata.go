@@ -0,0 +1,194 @@
+package cr2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata holds the common EXIF fields of a file, zero when missing.
+type Metadata struct {
+	Make                 string
+	Model                string
+	LensModel            string
+	SerialNumber         string
+	LensSerialNumber     string
+	InternalSerialNumber string
+	// DateTimeOriginal includes the sub-second digits, and is in UTC when
+	// the file does not record its time zone.
+	DateTimeOriginal time.Time
+	ExposureTime     Rational
+	FNumber          float64
+	ISO              int
+	// FocalLength in millimeters
+	FocalLength float64
+	// ExposureBias in EV
+	ExposureBias float64
+	Flash        int
+	MeteringMode int
+	Orientation  int
+	Width        int
+	Height       int
+	Owner        string
+	Artist       string
+	Copyright    string
+}
+
+// Metadata returns the common EXIF fields of the file, from IFD#0, the EXIF
+// sub-IFD and the Canon maker note.
+func (cf *CR2File) Metadata() *Metadata {
+	ifd0, exif, maker := &cf.ifd0, &cf.exifSubIfd, &cf.makerNodeSubIfd
+	m := &Metadata{
+		Make:                 ifd0.stringTag(ExifImageMake),
+		Model:                ifd0.stringTag(ExifImageModel),
+		LensModel:            exif.stringTag(ExifPhotoLensModel),
+		SerialNumber:         exif.stringTag(ExifPhotoBodySerialNumber),
+		LensSerialNumber:     exif.stringTag(ExifPhotoLensSerialNumber),
+		InternalSerialNumber: maker.stringTag(ExifCanonInternalSerialNumber),
+		ExposureTime:         exif.rationalTag(ExifPhotoExposureTime),
+		FNumber:              exif.rationalTag(ExifPhotoFNumber).Float(),
+		FocalLength:          exif.rationalTag(ExifPhotoFocalLength).Float(),
+		ExposureBias:         exif.sRationalTag(ExifPhotoExposureBiasValue).Float(),
+		Owner:                exif.stringTag(ExifPhotoCameraOwnerName),
+		Artist:               ifd0.stringTag(ExifImageArtist),
+		Copyright:            ifd0.stringTag(ExifImageCopyright),
+	}
+	if m.LensModel == "" {
+		m.LensModel = maker.stringTag(ExifCanonLensModel)
+	}
+	if m.SerialNumber == "" {
+		if serial, err := maker.uintTag(ExifCanonSerialNumber); err == nil {
+			m.SerialNumber = strconv.FormatUint(uint64(serial), 10)
+		}
+	}
+	if m.Owner == "" {
+		m.Owner = maker.stringTag(ExifCanonOwnerName)
+	}
+	m.DateTimeOriginal = parseExifTime(exif.stringTag(ExifPhotoDateTimeOriginal), exif.stringTag(ExifPhotoSubSecTimeOriginal), exif.stringTag(ExifPhotoOffsetTimeOriginal))
+
+	// 65535 stands for a sensitivity too large for ISOSpeedRatings
+	if iso, err := exif.uintTag(ExifPhotoISOSpeedRatings); err == nil && iso != 0xffff {
+		m.ISO = int(iso)
+	} else if iso, err := exif.uintTag(ExifPhotoRecommendedExposureIndex); err == nil {
+		m.ISO = int(iso)
+	}
+	if flash, err := exif.uintTag(ExifPhotoFlash); err == nil {
+		m.Flash = int(flash)
+	}
+	if mode, err := exif.uintTag(ExifPhotoMeteringMode); err == nil {
+		m.MeteringMode = int(mode)
+	}
+	if orientation, err := ifd0.uintTag(ExifImageOrientation); err == nil {
+		m.Orientation = int(orientation)
+	}
+	width, errWidth := exif.uintTag(ExifPhotoPixelXDimension)
+	height, errHeight := exif.uintTag(ExifPhotoPixelYDimension)
+	if errWidth != nil || errHeight != nil {
+		width, _ = ifd0.uintTag(ExifImageWidth)
+		height, _ = ifd0.uintTag(ExifImageHeight)
+	}
+	m.Width, m.Height = int(width), int(height)
+	return m
+}
+
+// parseExifTime returns the time of an EXIF date, with its sub-second digits
+// and time zone offset when known.
+func parseExifTime(date string, subSec string, offset string) time.Time {
+	location := time.UTC
+	if zone, err := time.Parse("-07:00", offset); err == nil {
+		_, seconds := zone.Zone()
+		location = time.FixedZone(offset, seconds)
+	}
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", date, location)
+	if err != nil {
+		return time.Time{}
+	}
+	if digits, err := strconv.Atoi(subSec); err == nil && len(subSec) < 10 {
+		t = t.Add(time.Duration(digits) * time.Second / time.Duration(pow10(len(subSec))))
+	}
+	return t
+}
+
+func pow10(n int) int {
+	p := 1
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+// stringTag returns the value of an ASCII tag without its padding, "" when
+// missing.
+func (ifd *ImageFileDirectory) stringTag(tagID uint16) string {
+	entry := ifd.TagsById[tagID]
+	if entry == nil || entry.TagType != TagTypeString {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(entry.StringValue(ifd.ParentFile), "\x00"))
+}
+
+// rationalTag returns the first value of a RATIONAL tag, 0/0 when missing.
+func (ifd *ImageFileDirectory) rationalTag(tagID uint16) Rational {
+	if values := ifd.rationalsTag(tagID); len(values) > 0 {
+		return values[0]
+	}
+	return Rational{}
+}
+
+// rationalsTag returns the values of a RATIONAL tag, nil when missing.
+func (ifd *ImageFileDirectory) rationalsTag(tagID uint16) []Rational {
+	entry := ifd.TagsById[tagID]
+	if entry == nil || entry.TagType != TagTypeUrational {
+		return nil
+	}
+	switch v := entry.Value(ifd.ParentFile).(type) {
+	case Rational:
+		return []Rational{v}
+	case []Rational:
+		return v
+	}
+	return nil
+}
+
+// sRationalTag returns the first value of an SRATIONAL tag, 0/0 when
+// missing.
+func (ifd *ImageFileDirectory) sRationalTag(tagID uint16) SRational {
+	entry := ifd.TagsById[tagID]
+	if entry == nil || entry.TagType != TagTypeRational {
+		return SRational{}
+	}
+	switch v := entry.Value(ifd.ParentFile).(type) {
+	case SRational:
+		return v
+	case []SRational:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return SRational{}
+}
+
+// Float returns the value of the rational, 0 when the denominator is 0.
+func (r Rational) Float() float64 {
+	if r.Denominator == 0 {
+		return 0
+	}
+	return float64(r.Numerator) / float64(r.Denominator)
+}
+
+func (r Rational) String() string {
+	return fmt.Sprintf("%d/%d", r.Numerator, r.Denominator)
+}
+
+// Float returns the value of the rational, 0 when the denominator is 0.
+func (r SRational) Float() float64 {
+	if r.Denominator == 0 {
+		return 0
+	}
+	return float64(r.Numerator) / float64(r.Denominator)
+}
+
+func (r SRational) String() string {
+	return fmt.Sprintf("%d/%d", r.Numerator, r.Denominator)
+}
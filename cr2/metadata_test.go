@@ -0,0 +1,39 @@
+package cr2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExifTime(t *testing.T) {
+	tests := []struct {
+		date, subSec, offset string
+		want                 time.Time
+		// offset of the zone of the result, in seconds east of UTC
+		zone int
+	}{
+		{"2023:05:17 14:30:15", "", "", time.Date(2023, 5, 17, 14, 30, 15, 0, time.UTC), 0},
+		{"2023:05:17 14:30:15", "25", "", time.Date(2023, 5, 17, 14, 30, 15, 250000000, time.UTC), 0},
+		{"2023:05:17 14:30:15", "007", "", time.Date(2023, 5, 17, 14, 30, 15, 7000000, time.UTC), 0},
+		{"2023:05:17 14:30:15", "123456789", "", time.Date(2023, 5, 17, 14, 30, 15, 123456789, time.UTC), 0},
+		// more digits than nanoseconds, or none, are ignored
+		{"2023:05:17 14:30:15", "1234567890", "", time.Date(2023, 5, 17, 14, 30, 15, 0, time.UTC), 0},
+		{"2023:05:17 14:30:15", "  ", "", time.Date(2023, 5, 17, 14, 30, 15, 0, time.UTC), 0},
+		{"2023:05:17 14:30:15", "", "+02:00", time.Date(2023, 5, 17, 12, 30, 15, 0, time.UTC), 7200},
+		{"2023:05:17 14:30:15", "5", "-05:30", time.Date(2023, 5, 17, 20, 0, 15, 500000000, time.UTC), -19800},
+		// unknown offset, the time is taken as UTC
+		{"2023:05:17 14:30:15", "", "   :  ", time.Date(2023, 5, 17, 14, 30, 15, 0, time.UTC), 0},
+		// unknown date
+		{"0000:00:00 00:00:00", "", "", time.Time{}, 0},
+		{"", "10", "+01:00", time.Time{}, 0},
+	}
+	for _, test := range tests {
+		got := parseExifTime(test.date, test.subSec, test.offset)
+		if !got.Equal(test.want) {
+			t.Errorf("parseExifTime(%q, %q, %q) = %v, want %v", test.date, test.subSec, test.offset, got, test.want)
+		}
+		if _, zone := got.Zone(); zone != test.zone {
+			t.Errorf("parseExifTime(%q, %q, %q) in zone %+d s, want %+d s", test.date, test.subSec, test.offset, zone, test.zone)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package cr2
+
+import (
+	"github.com/lpautet/cr2cv/ljpeg"
+	"testing"
+)
+
+func TestFullRawCorruptStream(t *testing.T) {
+	data := readFixture(t)
+	offset, err := openBytes(t, data).ifd3.uintTag(ExifImageStripOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SOI marker of the lossless JPEG stream
+	data[offset], data[offset+1] = 0, 0
+	_, err = openBytes(t, data).FullRaw()
+	fe, ok := err.(*FormatError)
+	if !ok {
+		t.Fatalf("got error %v, want a *FormatError", err)
+	}
+	if fe.Section != "Image#3" || fe.Offset < int64(offset) {
+		t.Errorf("got %s@%d, want Image#3 at or after %d", fe.Section, fe.Offset, offset)
+	}
+	if _, ok := fe.Err.(*ljpeg.FormatError); !ok {
+		t.Errorf("wrapped error %v, want a *ljpeg.FormatError", fe.Err)
+	}
+}
@@ -0,0 +1,249 @@
+package cr2
+
+import (
+	"github.com/lpautet/cr2cv/demosaic"
+	"image"
+	"image/color"
+)
+
+type CFAColor uint8
+
+const (
+	CFARed CFAColor = iota
+	CFAGreen
+	CFABlue
+)
+
+func (c CFAColor) String() string {
+	switch c {
+	case CFARed:
+		return "R"
+	case CFAGreen:
+		return "G"
+	case CFABlue:
+		return "B"
+	}
+	return "?"
+}
+
+// CFAPattern lists the colours of the 2x2 colour filter tile, left to right
+// and top to bottom, for the tile whose top left corner is at an even x and y.
+type CFAPattern [4]CFAColor
+
+var CFAPatternRGGB = CFAPattern{CFARed, CFAGreen, CFAGreen, CFABlue}
+
+// Index returns the position in the 2x2 tile of the sample at x, y.
+func (p CFAPattern) Index(x, y int) int {
+	return (y&1)<<1 | x&1
+}
+
+func (p CFAPattern) Color(x, y int) CFAColor {
+	return p[p.Index(x, y)]
+}
+
+func (p CFAPattern) String() string {
+	return p[0].String() + p[1].String() + p[2].String() + p[3].String()
+}
+
+// RawImage holds the unscaled sensor samples of a CFA raw image, one sample
+// per pixel. Black and white levels are given per position in the CFA tile.
+type RawImage struct {
+	Pix        []uint16
+	Stride     int
+	Rect       image.Rectangle
+	CFA        CFAPattern
+	Precision  uint8
+	BlackLevel [4]uint16
+	WhiteLevel uint16
+}
+
+func NewRawImage(r image.Rectangle, precision uint8) *RawImage {
+	return &RawImage{
+		Pix:        make([]uint16, r.Dx()*r.Dy()),
+		Stride:     r.Dx(),
+		Rect:       r,
+		CFA:        CFAPatternRGGB,
+		Precision:  precision,
+		WhiteLevel: uint16(1<<precision - 1),
+	}
+}
+
+func (r *RawImage) ColorModel() color.Model {
+	return color.Gray16Model
+}
+
+func (r *RawImage) Bounds() image.Rectangle {
+	return r.Rect
+}
+
+// At returns the unscaled sensor sample as a Gray16 colour.
+func (r *RawImage) At(x, y int) color.Color {
+	return r.Gray16At(x, y)
+}
+
+func (r *RawImage) Gray16At(x, y int) color.Gray16 {
+	if !(image.Point{X: x, Y: y}.In(r.Rect)) {
+		return color.Gray16{}
+	}
+	return color.Gray16{Y: r.Pix[r.PixOffset(x, y)]}
+}
+
+func (r *RawImage) PixOffset(x, y int) int {
+	return (y-r.Rect.Min.Y)*r.Stride + (x - r.Rect.Min.X)
+}
+
+func (r *RawImage) Sample(x, y int) uint16 {
+	if !(image.Point{X: x, Y: y}.In(r.Rect)) {
+		return 0
+	}
+	return r.Pix[r.PixOffset(x, y)]
+}
+
+func (r *RawImage) SetSample(x, y int, v uint16) {
+	if !(image.Point{X: x, Y: y}.In(r.Rect)) {
+		return
+	}
+	r.Pix[r.PixOffset(x, y)] = v
+}
+
+// Color returns the colour of the filter in front of the sample at x, y.
+func (r *RawImage) Color(x, y int) CFAColor {
+	return r.CFA.Color(x, y)
+}
+
+// SubImage returns the part of the raw image visible through rect, sharing
+// its samples. Coordinates are kept, so the CFA pattern still applies.
+func (r *RawImage) SubImage(rect image.Rectangle) *RawImage {
+	rect = rect.Intersect(r.Rect)
+	sub := *r
+	if rect.Empty() {
+		sub.Pix = nil
+		sub.Rect = image.Rectangle{}
+		return &sub
+	}
+	i := r.PixOffset(rect.Min.X, rect.Min.Y)
+	sub.Pix = r.Pix[i:]
+	sub.Rect = rect
+	return &sub
+}
+
+// Clipped reports whether the sample at x, y reached the white level.
+func (r *RawImage) Clipped(x, y int) bool {
+	if !(image.Point{X: x, Y: y}.In(r.Rect)) {
+		return false
+	}
+	return r.Pix[r.PixOffset(x, y)] >= r.WhiteLevel
+}
+
+// ClippingMask returns an image that is opaque where the sensor saturated.
+func (r *RawImage) ClippingMask() *image.Alpha {
+	mask := image.NewAlpha(r.Rect)
+	for y := r.Rect.Min.Y; y < r.Rect.Max.Y; y++ {
+		row := r.Pix[r.PixOffset(r.Rect.Min.X, y):]
+		dst := mask.Pix[mask.PixOffset(r.Rect.Min.X, y):]
+		for x := 0; x < r.Rect.Dx(); x++ {
+			if row[x] >= r.WhiteLevel {
+				dst[x] = 0xff
+			}
+		}
+	}
+	return mask
+}
+
+// Clone returns a copy of the raw image not sharing its samples.
+func (r *RawImage) Clone() *RawImage {
+	c := *r
+	c.Stride = r.Rect.Dx()
+	c.Pix = make([]uint16, r.Rect.Dx()*r.Rect.Dy())
+	for y := r.Rect.Min.Y; y < r.Rect.Max.Y; y++ {
+		i := r.PixOffset(r.Rect.Min.X, y)
+		copy(c.Pix[(y-r.Rect.Min.Y)*c.Stride:], r.Pix[i:i+r.Rect.Dx()])
+	}
+	return &c
+}
+
+// MeasureBlackLevel averages the samples of area, per position in the CFA
+// tile. It fails if area does not cover a full CFA tile of the image.
+func (r *RawImage) MeasureBlackLevel(area image.Rectangle) ([4]uint16, bool) {
+	area = area.Intersect(r.Rect)
+	var sum [4]uint64
+	var count [4]uint64
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		row := r.Pix[r.PixOffset(area.Min.X, y):]
+		for x := area.Min.X; x < area.Max.X; x++ {
+			i := r.CFA.Index(x, y)
+			sum[i] += uint64(row[x-area.Min.X])
+			count[i]++
+		}
+	}
+	var black [4]uint16
+	for i := range black {
+		if count[i] == 0 {
+			return black, false
+		}
+		black[i] = uint16((sum[i] + count[i]/2) / count[i])
+	}
+	return black, true
+}
+
+// SubtractBlack subtracts the black level from every sample, clipping at
+// zero. The white level is lowered by the largest black level so that it
+// stays valid for every channel, and the black level is reset.
+func (r *RawImage) SubtractBlack() {
+	var max uint16
+	for _, black := range r.BlackLevel {
+		if black > max {
+			max = black
+		}
+	}
+	if max == 0 {
+		return
+	}
+	for y := r.Rect.Min.Y; y < r.Rect.Max.Y; y++ {
+		row := r.Pix[r.PixOffset(r.Rect.Min.X, y):]
+		for x := r.Rect.Min.X; x < r.Rect.Max.X; x++ {
+			black := r.BlackLevel[r.CFA.Index(x, y)]
+			v := &row[x-r.Rect.Min.X]
+			if *v > black {
+				*v -= black
+			} else {
+				*v = 0
+			}
+		}
+	}
+	if r.WhiteLevel > max {
+		r.WhiteLevel -= max
+	} else {
+		r.WhiteLevel = 0
+	}
+	r.BlackLevel = [4]uint16{}
+}
+
+// Mosaic returns the samples scaled between the black and white levels,
+// ready to be demosaiced.
+func (r *RawImage) Mosaic() *demosaic.Mosaic {
+	return r.ScaledMosaic([3]float32{1, 1, 1})
+}
+
+// ScaledMosaic is like Mosaic but also multiplies the red, green and blue
+// samples by the given white balance multipliers. Scaled values may exceed 1.
+func (r *RawImage) ScaledMosaic(multipliers [3]float32) *demosaic.Mosaic {
+	m := demosaic.NewMosaic(r.Rect, demosaic.Pattern{
+		demosaic.Color(r.CFA[0]), demosaic.Color(r.CFA[1]), demosaic.Color(r.CFA[2]), demosaic.Color(r.CFA[3]),
+	})
+	var scale [4]float32
+	for i, black := range r.BlackLevel {
+		if r.WhiteLevel > black {
+			scale[i] = multipliers[r.CFA[i]] / float32(r.WhiteLevel-black)
+		}
+	}
+	for y := r.Rect.Min.Y; y < r.Rect.Max.Y; y++ {
+		row := r.Pix[r.PixOffset(r.Rect.Min.X, y):]
+		out := m.Pix[(y-r.Rect.Min.Y)*m.Stride:]
+		for x := 0; x < r.Rect.Dx(); x++ {
+			i := r.CFA.Index(x+r.Rect.Min.X, y)
+			out[x] = (float32(row[x]) - float32(r.BlackLevel[i])) * scale[i]
+		}
+	}
+	return m
+}
@@ -0,0 +1,47 @@
+package cr2
+
+// ScrubOptions lists the identifying tags removed by Scrub.
+type ScrubOptions struct {
+	// ExifTags are deleted from IFD#0 and the EXIF sub-IFD.
+	ExifTags []uint16
+	// CanonTags are zeroed in the maker note, so that no other maker note
+	// value moves.
+	CanonTags []uint16
+	// GPS deletes every tag of the GPS sub-IFD.
+	GPS bool
+}
+
+// DefaultScrubOptions removes the owner, the serial numbers and the location.
+var DefaultScrubOptions = ScrubOptions{
+	ExifTags:  []uint16{ExifPhotoCameraOwnerName, ExifPhotoBodySerialNumber, ExifPhotoLensSerialNumber},
+	CanonTags: []uint16{ExifCanonOwnerName, ExifCanonSerialNumber, ExifCanonInternalSerialNumber},
+	GPS:       true,
+}
+
+// Scrub removes identifying metadata from the file, to be saved with Write,
+// and returns the names of the tags removed or zeroed. The values of deleted
+// tags are cleared in the written file.
+func (cf *CR2File) Scrub(opts ScrubOptions) []string {
+	var scrubbed []string
+	for _, ifd := range []*ImageFileDirectory{&cf.ifd0, &cf.exifSubIfd} {
+		for _, tagID := range opts.ExifTags {
+			if ifd.DeleteTag(tagID) {
+				scrubbed = append(scrubbed, GetExifTagName(tagID))
+			}
+		}
+	}
+	for _, tagID := range opts.CanonTags {
+		if cf.makerNodeSubIfd.ZeroTag(tagID) {
+			scrubbed = append(scrubbed, GetCanonTagName(tagID))
+		}
+	}
+	if opts.GPS && cf.ifd0.DeleteTag(ExifImageGPSTag) {
+		scrubbed = append(scrubbed, GetExifTagName(ExifImageGPSTag))
+		for len(cf.gpsSubIfd.Entries) > 0 {
+			cf.gpsSubIfd.DeleteTag(cf.gpsSubIfd.Entries[0].TagID)
+		}
+		// no longer written, its table is cleared by Write
+		cf.gpsSubIfd.ParentFile = nil
+	}
+	return scrubbed
+}
@@ -0,0 +1,58 @@
+package cr2
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	data := readFixture(t)
+	cf := openBytes(t, data)
+	if _, err := cf.GPS(); err != nil {
+		t.Fatalf("fixture without GPS position: %v", err)
+	}
+	gpsOffset, gpsSize := int(cf.gpsSubIfd.Offset), ifdSize(cf.gpsSubIfd.capacity)
+	cf.Scrub(DefaultScrubOptions)
+	if _, err := cf.GPS(); err != ErrNoGPS {
+		t.Errorf("GPS of the scrubbed file: got error %v, want ErrNoGPS", err)
+	}
+	for _, ifd := range cf.ifds() {
+		if ifd == &cf.gpsSubIfd {
+			t.Error("the GPS IFD is still written")
+		}
+	}
+	out := write(t, cf)
+
+	scrubbed := openBytes(t, out)
+	if _, err := scrubbed.GPS(); err != ErrNoGPS {
+		t.Errorf("GPS of the written file: got error %v, want ErrNoGPS", err)
+	}
+	for i, b := range out[gpsOffset : gpsOffset+gpsSize] {
+		if b != 0 {
+			t.Fatalf("GPS IFD byte %d not cleared: %#x", i, b)
+		}
+	}
+	if bytes.Contains(out, rationals(testLatitude...)) {
+		t.Error("GPS latitude still in the written file")
+	}
+	for _, serial := range []string{testBodySerial, testInternalSerial, testLensSerial} {
+		if bytes.Contains(out, []byte(serial)) {
+			t.Errorf("serial number %q still in the written file", serial)
+		}
+	}
+	// the Canon owner is zeroed, the artist and copyright are kept
+	if bytes.Contains(out, []byte(testOwner)) {
+		t.Error("owner name still in the written file")
+	}
+	m := scrubbed.Metadata()
+	if m.Owner != "" || m.SerialNumber != "" || m.LensSerialNumber != "" || m.InternalSerialNumber != "" {
+		t.Errorf("got Owner %q, SerialNumber %q, LensSerialNumber %q, InternalSerialNumber %q, want them empty",
+			m.Owner, m.SerialNumber, m.LensSerialNumber, m.InternalSerialNumber)
+	}
+	if m.Artist != testArtist || m.Copyright != testCopyright || m.Model != "Canon EOS 5D Mark III" {
+		t.Errorf("got Artist %q, Copyright %q, Model %q, want them kept", m.Artist, m.Copyright, m.Model)
+	}
+	if _, err := scrubbed.FullRaw(); err != nil {
+		t.Errorf("FullRaw: %v", err)
+	}
+}
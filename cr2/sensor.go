@@ -0,0 +1,90 @@
+package cr2
+
+import (
+	"fmt"
+	"image"
+)
+
+// SensorInfo describes the sensor layout recorded in Exif.Canon.SensorInfo.
+type SensorInfo struct {
+	Width  int
+	Height int
+	// Active is the area of the sensor holding image data
+	Active image.Rectangle
+	// BlackMask is the optically masked area the black level is measured
+	// on
+	BlackMask image.Rectangle
+}
+
+func decodeSensorInfo(values []uint16) (*SensorInfo, error) {
+	if len(values) < 13 {
+		return nil, fmt.Errorf("SensorInfo too short: %d values", len(values))
+	}
+	si := &SensorInfo{
+		Width:  int(values[1]),
+		Height: int(values[2]),
+		// borders are inclusive
+		Active: image.Rect(int(values[5]), int(values[6]), int(values[7])+1, int(values[8])+1),
+	}
+	if values[11] > values[9] && values[12] > values[10] {
+		si.BlackMask = image.Rect(int(values[9]), int(values[10]), int(values[11])+1, int(values[12])+1)
+	} else {
+		// masked columns left of the active area
+		si.BlackMask = image.Rect(0, si.Active.Min.Y, si.Active.Min.X, si.Active.Max.Y)
+	}
+	if si.Active.Empty() {
+		return nil, fmt.Errorf("empty active area %v", si.Active)
+	}
+	return si, nil
+}
+
+// SensorInfo decodes the Canon SensorInfo tag.
+func (cf *CR2File) SensorInfo() (*SensorInfo, error) {
+	values, err := cf.makerNodeSubIfd.uint16ArrayTag(ExifCanonSensorInfo)
+	if err != nil {
+		return nil, err
+	}
+	si, err := decodeSensorInfo(values)
+	if err != nil {
+		entry := cf.makerNodeSubIfd.TagsById[ExifCanonSensorInfo]
+		return nil, wrapError(int64(entry.DataOrOffset), GetCanonTagName(ExifCanonSensorInfo), err)
+	}
+	return si, nil
+}
+
+// Raw returns the active area of the sensor data, with the black level
+// measured on the masked border subtracted and the white level of the shot.
+// Files without SensorInfo give the full frame.
+func (cf *CR2File) Raw() (*RawImage, error) {
+	img, err := cf.active.get(func() (image.Image, error) {
+		full, err := cf.FullRaw()
+		if err != nil {
+			return nil, err
+		}
+		var raw *RawImage
+		if cf.makerNodeSubIfd.TagsById[ExifCanonSensorInfo] == nil {
+			// a copy, the samples of the cached full frame being left as is
+			raw = full.Clone()
+		} else {
+			si, err := cf.SensorInfo()
+			if err != nil {
+				return nil, err
+			}
+			active := si.Active.Intersect(full.Rect)
+			if active.Empty() {
+				return nil, formatErrorf(cf.makerNodeSubIfd.Offset, cf.makerNodeSubIfd.Name, "active area %v outside of raw image %v", si.Active, full.Rect)
+			}
+			raw = full.SubImage(active).Clone()
+			if black, ok := full.MeasureBlackLevel(si.BlackMask); ok {
+				raw.BlackLevel = black
+			}
+		}
+		raw.WhiteLevel = cf.whiteLevel(raw.WhiteLevel)
+		raw.SubtractBlack()
+		return raw, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return img.(*RawImage), nil
+}
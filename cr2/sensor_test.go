@@ -0,0 +1,28 @@
+package cr2
+
+import "testing"
+
+func TestRawWithoutSensorInfoCopiesFullRaw(t *testing.T) {
+	cf := openBytes(t, readFixture(t))
+	cf.makerNodeSubIfd.DeleteTag(ExifCanonSensorInfo)
+	full, err := cf.FullRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := full.Clone()
+	raw, err := cf.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.Bounds() != full.Bounds() {
+		t.Fatalf("Raw bounds %v, want the full frame %v", raw.Bounds(), full.Bounds())
+	}
+	raw.BlackLevel = [4]uint16{1000, 1000, 1000, 1000}
+	raw.SubtractBlack()
+	raw.SetSample(raw.Rect.Min.X, raw.Rect.Min.Y, 1)
+	for i := range full.Pix {
+		if full.Pix[i] != before.Pix[i] {
+			t.Fatalf("FullRaw sample %d changed from %d to %d", i, before.Pix[i], full.Pix[i])
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package cr2
+
+import (
+	"fmt"
+	"github.com/lpautet/cr2cv/internal/imageutil"
+	"image"
+	"strings"
+)
+
+// sRAW pixels saturate at this level once converted to RGB
+const srawWhiteLevel = 0x3fff
+
+// Models using the first YCbCr to RGB conversion
+var srawOldModels = map[uint32]bool{
+	0x80000218: true, // 5D Mark II
+	0x80000250: true, // 7D
+	0x80000261: true, // 50D
+	0x80000281: true, // 1D Mark IV
+	0x80000287: true, // 60D
+}
+
+type srawParams struct {
+	modelID uint32
+	// firmware version as major*1000000 + minor*1000 + patch
+	firmware int
+	// red, green and blue scale, 1024 being 1
+	levels [3]int
+}
+
+func (cf *CR2File) srawParams() srawParams {
+	p := srawParams{levels: [3]int{1024, 1024, 1024}}
+	p.modelID, _ = cf.makerNodeSubIfd.uintTag(ExifCanonModelID)
+	if entry := cf.makerNodeSubIfd.TagsById[ExifCanonFirmwareVersion]; entry != nil && entry.TagType == TagTypeString {
+		var major, minor, patch int
+		version := strings.TrimPrefix(strings.TrimSpace(entry.StringValue(cf)), "Firmware Version ")
+		if _, err := fmt.Sscanf(version, "%d.%d.%d", &major, &minor, &patch); err == nil {
+			p.firmware = (major*1000+minor)*1000 + patch
+		}
+	}
+	if cd, err := cf.ColorData(); err == nil && cd.SRAWLevels[1] != 0 {
+		p.levels = [3]int{int(cd.SRAWLevels[0]), int(cd.SRAWLevels[1]), int(cd.SRAWLevels[3])}
+	}
+	return p
+}
+
+// srawToRGB turns the Y, Cb, Cr values decoded from an sRAW (2 luma samples
+// per MCU) or mRAW (4 luma samples) scan into linear camera RGB, the white
+// level mapped to 0xffff.
+func srawToRGB(grid *RawImage, lumaSamples int, p srawParams, workers int) *image.RGBA64 {
+	slots := lumaSamples + 2
+	mcuHeight := lumaSamples / 2
+	width := grid.Rect.Dx() / slots * 2
+	height := grid.Rect.Dy() * mcuHeight
+	ycc := make([]int32, 3*width*height)
+	imageutil.ParallelFor(grid.Rect.Dy(), workers, func(j int) {
+		row := grid.Pix[j*grid.Stride:]
+		for m := 0; m < width/2; m++ {
+			mcu := row[m*slots : (m+1)*slots]
+			y, x := j*mcuHeight, 2*m
+			for c := 0; c < lumaSamples; c++ {
+				ycc[3*((y+c>>1)*width+x+c&1)] = int32(mcu[c])
+			}
+			ycc[3*(y*width+x)+1] = int32(mcu[lumaSamples]) - 16384
+			ycc[3*(y*width+x)+2] = int32(mcu[lumaSamples+1]) - 16384
+		}
+	})
+	// chroma of the odd rows of mRAW, then of the odd columns
+	if mcuHeight == 2 {
+		imageutil.ParallelFor(height/2, workers, func(j int) {
+			y := 2*j + 1
+			for x := 0; x < width; x += 2 {
+				for c := 1; c < 3; c++ {
+					i := 3*(y*width+x) + c
+					if y == height-1 {
+						ycc[i] = ycc[i-3*width]
+					} else {
+						ycc[i] = (ycc[i-3*width] + ycc[i+3*width] + 1) >> 1
+					}
+				}
+			}
+		})
+	}
+	imageutil.ParallelFor(height, workers, func(y int) {
+		row := ycc[3*y*width : 3*(y+1)*width]
+		for x := 1; x < width; x += 2 {
+			for c := 1; c < 3; c++ {
+				i := 3*x + c
+				if x == width-1 {
+					row[i] = row[i-3]
+				} else {
+					row[i] = (row[i-3] + row[i+3] + 1) >> 1
+				}
+			}
+		}
+	})
+
+	sraw := int32(lumaSamples - 1)
+	hue := (sraw + 1) << 2
+	if p.modelID >= 0x80000281 || (p.modelID == 0x80000218 && p.firmware > 1000006) {
+		hue = sraw << 1
+	}
+	out := image.NewRGBA64(image.Rect(0, 0, width, height))
+	imageutil.ParallelFor(height, workers, func(y int) {
+		src := ycc[3*y*width:]
+		dst := out.Pix[y*out.Stride:]
+		for x := 0; x < width; x++ {
+			Y, Cb, Cr := src[3*x], src[3*x+1], src[3*x+2]
+			var pix [3]int32
+			if srawOldModels[p.modelID] {
+				Cb = Cb<<2 + hue
+				Cr = Cr<<2 + hue
+				pix[0] = Y + (50*Cb+22929*Cr)>>14
+				pix[1] = Y + (-5640*Cb-11751*Cr)>>14
+				pix[2] = Y + (29040*Cb-101*Cr)>>14
+			} else {
+				if p.modelID < 0x80000218 {
+					Y -= 512
+				}
+				pix[0] = Y + Cr
+				pix[1] = Y + (-778*Cb-Cr<<11)>>12
+				pix[2] = Y + Cb
+			}
+			for c, v := range pix {
+				v = clip16(v * int32(p.levels[c]) >> 10)
+				v = clip16(int32(int64(v) * 0xffff / srawWhiteLevel))
+				dst[8*x+2*c] = uint8(v >> 8)
+				dst[8*x+2*c+1] = uint8(v)
+			}
+			dst[8*x+6] = 0xff
+			dst[8*x+7] = 0xff
+		}
+	})
+	return out
+}
+
+func clip16(v int32) int32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 0xffff {
+		return 0xffff
+	}
+	return v
+}
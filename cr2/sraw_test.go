@@ -0,0 +1,125 @@
+package cr2
+
+import (
+	"bytes"
+	"github.com/lpautet/cr2cv/ljpeg"
+	"testing"
+)
+
+// srawStream encodes rows of MCUs, each made of lumaSamples Y values then Cb
+// and Cr, as the 15 bit scan of an sRAW (2 luma samples, 2x1) or mRAW (4, 2x2)
+// image. Chroma is given signed and stored offset by 16384.
+func srawStream(t *testing.T, lumaSamples int, mcus [][][]int) []byte {
+	img := &ljpeg.Image{
+		Precision:  15,
+		Width:      2 * len(mcus[0]),
+		Height:     lumaSamples / 2 * len(mcus),
+		Components: []ljpeg.Component{{ID: 1, H: 2, V: lumaSamples / 2}, {ID: 2, H: 1, V: 1}, {ID: 3, H: 1, V: 1}},
+		Predictor:  1,
+	}
+	for _, row := range mcus {
+		for _, mcu := range row {
+			for i, v := range mcu {
+				if i >= lumaSamples {
+					v += 16384
+				}
+				img.Pix = append(img.Pix, uint16(v))
+			}
+		}
+	}
+	var data bytes.Buffer
+	if err := ljpeg.Encode(&data, img, &ljpeg.Options{CanonSRAW: true}); err != nil {
+		t.Fatal(err)
+	}
+	return data.Bytes()
+}
+
+var (
+	testSRAW = [][][]int{
+		{{4000, 4100, 1000, -500}, {5000, 5200, -200, 300}},
+		{{3000, 3000, 0, 0}, {6000, 6100, 400, 800}},
+	}
+	testMRAW = [][][]int{
+		{{4000, 4100, 4200, 4300, 1000, -500}, {5000, 5100, 5200, 5300, -200, 300}},
+		{{3000, 3000, 3000, 3000, 0, 0}, {6000, 6100, 6200, 6300, 400, 800}},
+	}
+)
+
+// The expected camera RGB values are computed from the Canon conversions, the
+// chroma of odd columns (and odd mRAW rows) being the rounded mean of its
+// neighbours, or a copy of the previous one on the last column (row). For the
+// first pixel of testSRAW, Y 4000, Cb 1000 and Cr -500:
+//   - new models: R = Y + Cr = 3500, G = Y + (-778*Cb - Cr*2048) >> 12 = 4060,
+//     B = Y + Cb = 5000, Y being lowered by 512 before the 5D Mark II
+//   - old models, with Cb' = 4*Cb + hue and Cr' = 4*Cr + hue:
+//     R = Y + (50*Cb' + 22929*Cr') >> 14,
+//     G = Y + (-5640*Cb' - 11751*Cr') >> 14,
+//     B = Y + (29040*Cb' - 101*Cr') >> 14, hue being 8 for sRAW (1224, 4049,
+//     11116), and 2 once the 5D Mark II firmware fixed it (1216, 4055, 11105)
+func TestSRAWToRGB(t *testing.T) {
+	unity := [3]int{1024, 1024, 1024}
+	tests := []struct {
+		name        string
+		lumaSamples int
+		mcus        [][][]int
+		params      srawParams
+		want        [][][3]int
+	}{
+		{"5D Mark III sRAW", 2, testSRAW, srawParams{0x80000285, 1001000, unity}, [][][3]int{
+			{{3500, 4060, 5000}, {4000, 4074, 4500}, {5300, 4887, 4800}, {5500, 5087, 5000}},
+			{{3000, 3000, 3000}, {3400, 2762, 3200}, {6800, 5524, 6400}, {6900, 5624, 6500}},
+		}},
+		// red and blue scaled by the sRAW levels of ColorData
+		{"5D Mark III mRAW", 4, testMRAW, srawParams{0x80000285, 1001000, [3]int{1100, 1024, 1250}}, [][][3]int{
+			{{3759, 4060, 6103}, {4296, 4074, 5493}, {5693, 4887, 5859}, {5800, 4987, 5981}},
+			{{4243, 4230, 5737}, {4780, 4168, 5615}, {6176, 4906, 6469}, {6284, 5006, 6591}},
+			{{3222, 3000, 3662}, {3652, 2762, 3906}, {7304, 5524, 7812}, {7412, 5624, 7934}},
+			{{3222, 3000, 3662}, {3652, 2762, 3906}, {7519, 5724, 8056}, {7626, 5824, 8178}},
+		}},
+		{"1D Mark III sRAW", 2, testSRAW, srawParams{0x80000169, 1001000, unity}, [][][3]int{
+			{{2988, 3548, 4488}, {3488, 3562, 3988}, {4788, 4375, 4288}, {4988, 4575, 4488}},
+			{{2488, 2488, 2488}, {2888, 2250, 2688}, {6288, 5012, 5888}, {6388, 5112, 5988}},
+		}},
+		{"5D Mark II 1.0.6 sRAW", 2, testSRAW, srawParams{0x80000218, 1000006, unity}, [][][3]int{
+			{{1224, 4049, 11116}, {3556, 3827, 6952}, {6688, 4406, 3588}, {6888, 4606, 3788}},
+			{{3011, 2991, 3014}, {5252, 1568, 4422}, {10494, 3145, 8830}, {10594, 3245, 8930}},
+		}},
+		{"5D Mark II 1.0.7 sRAW", 2, testSRAW, srawParams{0x80000218, 1000007, unity}, [][][3]int{
+			{{1216, 4055, 11105}, {3547, 3833, 6941}, {6679, 4412, 3578}, {6879, 4612, 3778}},
+			{{3002, 2997, 3003}, {5244, 1574, 4411}, {10486, 3151, 8819}, {10586, 3251, 8919}},
+		}},
+		// hue of 6, 2*(4 - 1), from the 1D Mark IV on
+		{"1D Mark IV mRAW", 4, testMRAW, srawParams{0x80000281, 1001000, unity}, [][][3]int{
+			{{1221, 4051, 11112}, {3553, 3829, 6949}, {6685, 4408, 3585}, {6785, 4508, 3685}},
+			{{2815, 4222, 7761}, {5151, 3450, 6433}, {8288, 3478, 5906}, {8388, 3578, 6006}},
+			{{3008, 2993, 3010}, {5250, 1570, 4418}, {10491, 3147, 8826}, {10591, 3247, 8926}},
+			{{3008, 2993, 3010}, {5250, 1570, 4418}, {10691, 3347, 9026}, {10791, 3447, 9126}},
+		}},
+	}
+	for _, test := range tests {
+		raw, lumaSamples, err := readRawImage(srawStream(t, test.lumaSamples, test.mcus), 0, Slice{}, 1)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if lumaSamples != test.lumaSamples {
+			t.Fatalf("%s: %d luma samples, want %d", test.name, lumaSamples, test.lumaSamples)
+		}
+		out := srawToRGB(raw, lumaSamples, test.params, 2)
+		if size := out.Rect.Size(); size.X != len(test.want[0]) || size.Y != len(test.want) {
+			t.Fatalf("%s: size %v, want %dx%d", test.name, size, len(test.want[0]), len(test.want))
+		}
+		for y, row := range test.want {
+			for x, want := range row {
+				c := out.RGBA64At(x, y)
+				// the sRAW white level is mapped to 0xffff
+				got := [3]int{int(c.R), int(c.G), int(c.B)}
+				for i := range want {
+					want[i] = want[i] * 0xffff / srawWhiteLevel
+				}
+				if got != want {
+					t.Errorf("%s: pixel (%d, %d) is %v, want %v", test.name, x, y, got, want)
+				}
+			}
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package cr2
 
 import (
-	"fmt"
 	"github.com/lpautet/cr2cv/bufreader"
 )
 
@@ -11,12 +10,15 @@ type TiffHeader struct {
 	TiffOffset uint32
 }
 
-func (th *TiffHeader) readFrom(reader *bufreader.BufferReader) {
-	reader.ReadInto(8, th)
+func (th *TiffHeader) readFrom(reader *bufreader.BufferReader) error {
+	if err := reader.ReadInto(8, th); err != nil {
+		return wrapError(reader.Offset, "TIFF header", err)
+	}
 	if (th.ByteOrder[0] != 'I') || (th.ByteOrder[1] != 'I') {
-		panic(fmt.Sprintf("Unsuported byte order\n"))
+		return ErrUnsupportedByteOrder
 	}
 	if th.TiffMagic != 0x002a {
-		panic(fmt.Sprintf("Invalid TIFF magic\n"))
+		return ErrBadMagic
 	}
+	return nil
 }
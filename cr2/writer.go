@@ -0,0 +1,252 @@
+package cr2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Write writes cf as a CR2 file. The bytes of the file cf was read from are
+// kept where they were, image data included, and the headers, the IFDs and
+// the edited tag values are serialised over them at their offsets. Values
+// not set or zeroed through the ImageFileDirectory methods keep their bytes
+// and count, so that a file written back unmodified is byte-identical to the
+// source. Values and IFDs which no longer fit in their original space are
+// appended to the end of the file and the offsets pointing to them updated.
+// The maker note is never moved, as Canon maker notes hold absolute offsets.
+func Write(w io.Writer, cf *CR2File) error {
+	if cf.reader == nil {
+		return fmt.Errorf("cr2: nothing to write, the file was not read")
+	}
+	var source bytes.Buffer
+	if _, err := io.Copy(&source, io.NewSectionReader(cf.reader.Reader, 0, math.MaxInt64)); err != nil {
+		return err
+	}
+	fw := &fileWriter{cf: cf, buf: source.Bytes(), relocated: make(map[uint32]uint32)}
+	if err := fw.layout(); err != nil {
+		return err
+	}
+	fw.serialise()
+	_, err := w.Write(fw.buf)
+	return err
+}
+
+type fileWriter struct {
+	cf  *CR2File
+	buf []byte
+	// IFDs to write, in the order they are laid out
+	ifds []*ifdLayout
+
+	tiffHeader TiffHeader
+	cr2Header  CR2Header
+	// new offsets of the values moved to the end of the file
+	relocated map[uint32]uint32
+}
+
+// ifdLayout is an IFD as written: its offset, entries and next IFD offset.
+type ifdLayout struct {
+	ifd     *ImageFileDirectory
+	offset  uint32
+	entries []IFDEntry
+	next    uint32
+}
+
+// layout decides where every IFD and value goes, and updates the offsets
+// pointing to the ones moved.
+func (fw *fileWriter) layout() error {
+	cf := fw.cf
+	layouts := make(map[*ImageFileDirectory]*ifdLayout)
+	for _, ifd := range cf.ifds() {
+		l := &ifdLayout{ifd: ifd, offset: uint32(ifd.Offset), next: ifd.NextIFDOffset}
+		if ifd.Offset == 0 || len(ifd.Entries) > ifd.capacity {
+			if ifd == &cf.makerNodeSubIfd {
+				return fmt.Errorf("cr2: %s cannot grow from %d to %d entries", ifd.Name, ifd.capacity, len(ifd.Entries))
+			}
+			l.offset = fw.allocate(ifdSize(len(ifd.Entries)))
+		}
+		l.entries = make([]IFDEntry, len(ifd.Entries))
+		copy(l.entries, ifd.Entries)
+		layouts[ifd] = l
+		fw.ifds = append(fw.ifds, l)
+	}
+
+	// clear the values of deleted entries
+	used := make(map[uint32]bool)
+	for _, l := range fw.ifds {
+		for _, entry := range l.entries {
+			if entry.outOfLine() {
+				used[entry.DataOrOffset] = true
+			}
+		}
+	}
+	for offset, size := range cf.valueSizes {
+		if !used[offset] && int(offset)+size <= len(fw.buf) {
+			fw.clear(int(offset), int(offset)+size)
+		}
+	}
+	// and the table of the GPS IFD removed by Scrub
+	if gps := &cf.gpsSubIfd; gps.ParentFile == nil && gps.Offset != 0 {
+		fw.clear(int(gps.Offset), int(gps.Offset)+ifdSize(gps.capacity))
+	}
+
+	for _, l := range fw.ifds {
+		for j := range l.entries {
+			entry := &l.entries[j]
+			if l.ifd == &cf.exifSubIfd && entry.TagID == ExifPhotoMakerNote {
+				// the maker note is written as an IFD
+				continue
+			}
+			fw.layoutValue(entry)
+		}
+	}
+
+	// offsets pointing to the IFDs
+	fw.tiffHeader = cf.TiffHeader
+	fw.tiffHeader.TiffOffset = layouts[&cf.ifd0].offset
+	fw.cr2Header = cf.CR2Header
+	fw.cr2Header.RawIfdOffset = layouts[&cf.ifd3].offset
+	layouts[&cf.ifd0].setPointer(ExifImageExifTag, layouts[&cf.exifSubIfd])
+	layouts[&cf.ifd0].setPointer(ExifImageGPSTag, layouts[&cf.gpsSubIfd])
+	layouts[&cf.exifSubIfd].setPointer(ExifPhotoInteroperabilityTag, layouts[&cf.interopSubIfd])
+	layouts[&cf.ifd0].next = layouts[&cf.ifd1].offset
+	layouts[&cf.ifd1].next = layouts[&cf.ifd2].offset
+	if cf.ifd2.NextIFDOffset == uint32(cf.ifd3.Offset) {
+		layouts[&cf.ifd2].next = layouts[&cf.ifd3].offset
+	}
+	return nil
+}
+
+// setPointer sets the tag pointing to a sub-IFD, when both exist.
+func (l *ifdLayout) setPointer(tagID uint16, sub *ifdLayout) {
+	if sub == nil {
+		return
+	}
+	for j := range l.entries {
+		if l.entries[j].TagID == tagID {
+			l.entries[j].DataOrOffset = sub.offset
+		}
+	}
+}
+
+// layoutValue writes the value of an edited entry at its offset, or at the
+// end of the file when it does not fit there any more.
+func (fw *fileWriter) layoutValue(entry *IFDEntry) {
+	if !entry.outOfLine() || !fw.cf.edited[entry.DataOrOffset] {
+		return
+	}
+	data, ok := encodeValue(entry.TagType, fw.cf.ValuesByOffset[entry.DataOrOffset])
+	if !ok {
+		// unknown value, its bytes are kept
+		return
+	}
+	entry.NumberOfValues = uint32(len(data) / tagTypeSize(entry.TagType))
+	if !entry.outOfLine() {
+		var inline [4]byte
+		copy(inline[:], data)
+		entry.DataOrOffset = binary.LittleEndian.Uint32(inline[:])
+		return
+	}
+	if offset, ok := fw.relocated[entry.DataOrOffset]; ok {
+		entry.DataOrOffset = offset
+		return
+	}
+	offset := entry.DataOrOffset
+	size := fw.cf.valueSizes[offset]
+	if offset == 0 || len(data) > size {
+		// clear the old value, it is not used any more
+		if offset != 0 {
+			fw.clear(int(offset), int(offset)+size)
+		}
+		offset = fw.allocate(len(data))
+		fw.relocated[entry.DataOrOffset] = offset
+	} else {
+		fw.clear(int(offset)+len(data), int(offset)+size)
+	}
+	copy(fw.buf[offset:], data)
+	entry.DataOrOffset = offset
+}
+
+func (fw *fileWriter) clear(start, end int) {
+	for i := start; i < end; i++ {
+		fw.buf[i] = 0
+	}
+}
+
+// allocate returns the offset of size new bytes at the end of the file, word
+// aligned.
+func (fw *fileWriter) allocate(size int) uint32 {
+	offset := len(fw.buf) + len(fw.buf)&1
+	fw.buf = append(fw.buf, make([]byte, offset+size-len(fw.buf))...)
+	return uint32(offset)
+}
+
+// serialise writes the headers and the IFD tables.
+func (fw *fileWriter) serialise() {
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, fw.tiffHeader)
+	binary.Write(&header, binary.LittleEndian, fw.cr2Header)
+	copy(fw.buf, header.Bytes())
+
+	for _, l := range fw.ifds {
+		var table bytes.Buffer
+		binary.Write(&table, binary.LittleEndian, uint16(len(l.entries)))
+		binary.Write(&table, binary.LittleEndian, l.entries)
+		binary.Write(&table, binary.LittleEndian, l.next)
+		offset := int(l.offset)
+		if int64(offset) == l.ifd.Offset {
+			// clear the entries the IFD lost
+			fw.clear(offset+table.Len(), offset+ifdSize(l.ifd.capacity))
+		} else if l.ifd.Offset != 0 {
+			// clear the IFD moved to the end of the file
+			fw.clear(int(l.ifd.Offset), int(l.ifd.Offset)+ifdSize(l.ifd.capacity))
+		}
+		copy(fw.buf[offset:], table.Bytes())
+	}
+}
+
+func ifdSize(entries int) int {
+	return 2 + 12*entries + 4
+}
+
+// encodeValue returns the bytes of a value extracted for a tag type, false
+// when the value is not of the type.
+func encodeValue(tagType uint16, value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case string:
+		if tagType != TagTypeString {
+			return nil, false
+		}
+		if strings.HasSuffix(v, "\x00") {
+			return []byte(v), true
+		}
+		return append([]byte(v), 0), true
+	case []uint8:
+		if tagType != TagTypeUbyte && tagType != TagTypeByteSequence {
+			return nil, false
+		}
+	case []uint16:
+		if tagType != TagTypeUint16 {
+			return nil, false
+		}
+	case []uint32:
+		if tagType != TagTypeUint32 {
+			return nil, false
+		}
+	case Rational, []Rational:
+		if tagType != TagTypeUrational {
+			return nil, false
+		}
+	case SRational, []SRational:
+		if tagType != TagTypeRational {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, value)
+	return data.Bytes(), true
+}
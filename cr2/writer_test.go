@@ -0,0 +1,71 @@
+package cr2
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Software tag of the test file, an ASCII value of 8 bytes without a NUL
+const testSoftwareTag = 0x0131
+
+func write(t *testing.T, cf *CR2File) []byte {
+	var buf bytes.Buffer
+	if err := Write(&buf, cf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteUnmodified(t *testing.T) {
+	data := readFixture(t)
+	cf := openBytes(t, data)
+	if software := cf.IFD0().stringTag(testSoftwareTag); software != testSoftware {
+		t.Errorf("Software = %q, want %q", software, testSoftware)
+	}
+	out := write(t, cf)
+	if !bytes.Equal(out, data) {
+		for i := range data {
+			if i >= len(out) || out[i] != data[i] {
+				t.Fatalf("written file differs from the source at offset %d, %d bytes instead of %d", i, len(out), len(data))
+			}
+		}
+		t.Fatalf("written file has %d bytes instead of %d", len(out), len(data))
+	}
+}
+
+func TestWriteEdited(t *testing.T) {
+	cf := openBytes(t, readFixture(t))
+	cf.IFD0().SetString(testSoftwareTag, "CR2 test, edited")
+	cf.IFD0().SetString(ExifImageArtist, "T. Artist")
+	cf.IFD0().SetUint16(ExifImageOrientation, 6)
+	cf.ExifIFD().SetString(ExifPhotoLensModel, testLensModel+"\x00\x00")
+	cf.MakerNoteIFD().ZeroTag(ExifCanonOwnerName)
+	owner := cf.MakerNoteIFD().TagsById[ExifCanonOwnerName].NumberOfValues
+
+	out := openBytes(t, write(t, cf))
+	ifd0 := out.IFD0()
+	if software := ifd0.stringTag(testSoftwareTag); software != "CR2 test, edited" {
+		t.Errorf("Software = %q, want %q", software, "CR2 test, edited")
+	}
+	if count := ifd0.TagsById[testSoftwareTag].NumberOfValues; count != 17 {
+		t.Errorf("Software has %d bytes, want 17", count)
+	}
+	if count := out.ExifIFD().TagsById[ExifPhotoLensModel].NumberOfValues; count != 18 {
+		t.Errorf("LensModel has %d bytes, want the 18 set", count)
+	}
+	if count := out.MakerNoteIFD().TagsById[ExifCanonOwnerName].NumberOfValues; count != owner {
+		t.Errorf("zeroed OwnerName has %d bytes, want %d", count, owner)
+	}
+	m := out.Metadata()
+	want := Metadata{Artist: "T. Artist", Orientation: 6, LensModel: testLensModel, Model: "Canon EOS 5D Mark III", Copyright: testCopyright}
+	if m.Artist != want.Artist || m.Orientation != want.Orientation || m.LensModel != want.LensModel || m.Model != want.Model || m.Copyright != want.Copyright {
+		t.Errorf("got Artist %q, Orientation %d, LensModel %q, Model %q, Copyright %q, want %q, %d, %q, %q, %q",
+			m.Artist, m.Orientation, m.LensModel, m.Model, m.Copyright, want.Artist, want.Orientation, want.LensModel, want.Model, want.Copyright)
+	}
+	if owner := out.MakerNoteIFD().stringTag(ExifCanonOwnerName); owner != "" {
+		t.Errorf("OwnerName = %q, want it zeroed", owner)
+	}
+	if _, err := out.FullRaw(); err != nil {
+		t.Errorf("FullRaw: %v", err)
+	}
+}
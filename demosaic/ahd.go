@@ -0,0 +1,210 @@
+package demosaic
+
+import (
+	"github.com/lpautet/cr2cv/internal/imageutil"
+	"image"
+	"math"
+	"sync"
+)
+
+// Size of the square tiles AHD works on; neighbouring tiles overlap by 6
+// pixels so that every written pixel has a complete homogeneity window.
+const ahdTile = 256
+
+// Linear sRGB to XYZ (D65), divided by the white point.
+var ahdXYZ = [3][3]float32{
+	{0.412453 / 0.950456, 0.357580 / 0.950456, 0.180423 / 0.950456},
+	{0.212671, 0.715160, 0.072169},
+	{0.019334 / 1.088754, 0.119193 / 1.088754, 0.950227 / 1.088754},
+}
+
+type ahdBuffers struct {
+	// horizontal and vertical interpolations
+	rgb  [2][ahdTile * ahdTile][3]float32
+	lab  [2][ahdTile * ahdTile][3]float32
+	homo [2][ahdTile * ahdTile]uint8
+}
+
+var ahdPool = sync.Pool{
+	New: func() interface{} { return new(ahdBuffers) },
+}
+
+// ahd implements the Adaptive Homogeneity-Directed interpolation of Hirakawa
+// and Parks: the image is interpolated both horizontally and vertically and,
+// for each pixel, the direction giving the most homogeneous CIELab
+// neighbourhood is kept. A five pixel wide border keeps its bilinear values.
+func ahd(m *Mosaic, out *RGB, workers int) {
+	width := m.Rect.Dx()
+	height := m.Rect.Dy()
+	var tiles []image.Point
+	for top := 2; top < height-5; top += ahdTile - 6 {
+		for left := 2; left < width-5; left += ahdTile - 6 {
+			tiles = append(tiles, image.Pt(left, top))
+		}
+	}
+	imageutil.ParallelFor(len(tiles), workers, func(i int) {
+		buf := ahdPool.Get().(*ahdBuffers)
+		ahdTileDecode(m, out, buf, tiles[i].X, tiles[i].Y)
+		ahdPool.Put(buf)
+	})
+}
+
+func ahdTileDecode(m *Mosaic, out *RGB, buf *ahdBuffers, left, top int) {
+	width := m.Rect.Dx()
+	height := m.Rect.Dy()
+	rowEnd := top + ahdTile
+	if rowEnd > height-2 {
+		rowEnd = height - 2
+	}
+	colEnd := left + ahdTile
+	if colEnd > width-2 {
+		colEnd = width - 2
+	}
+
+	for d := 0; d < 2; d++ {
+		rgb := &buf.rgb[d]
+
+		// green, interpolated along the direction with a second order
+		// correction from the centre colour
+		for y := top; y < rowEnd; y++ {
+			for x := left; x < colEnd; x++ {
+				i := (y-top)*ahdTile + x - left
+				c := m.color(x, y)
+				v := m.at(x, y)
+				if c == Green {
+					rgb[i][Green] = v
+					continue
+				}
+				var a, b, a2, b2 float32
+				if d == 0 {
+					a, b, a2, b2 = m.at(x-1, y), m.at(x+1, y), m.at(x-2, y), m.at(x+2, y)
+				} else {
+					a, b, a2, b2 = m.at(x, y-1), m.at(x, y+1), m.at(x, y-2), m.at(x, y+2)
+				}
+				g := ((a+b)*2 + 2*v - a2 - b2) / 4
+				if a > b {
+					a, b = b, a
+				}
+				if g < a {
+					g = a
+				} else if g > b {
+					g = b
+				}
+				rgb[i][Green] = g
+				rgb[i][c] = v
+			}
+		}
+
+		// red and blue from the colour differences, then CIELab
+		for y := top + 1; y < rowEnd-1; y++ {
+			for x := left + 1; x < colEnd-1; x++ {
+				i := (y-top)*ahdTile + x - left
+				g := rgb[i][Green]
+				if c := m.color(x, y); c == Green {
+					h := m.color(x+1, y)
+					rgb[i][h] = clampPositive(g + (m.at(x-1, y)-rgb[i-1][Green]+m.at(x+1, y)-rgb[i+1][Green])/2)
+					v := m.color(x, y+1)
+					rgb[i][v] = clampPositive(g + (m.at(x, y-1)-rgb[i-ahdTile][Green]+m.at(x, y+1)-rgb[i+ahdTile][Green])/2)
+				} else {
+					o := m.color(x+1, y+1)
+					rgb[i][o] = clampPositive(g + (m.at(x-1, y-1)-rgb[i-ahdTile-1][Green]+
+						m.at(x+1, y-1)-rgb[i-ahdTile+1][Green]+
+						m.at(x-1, y+1)-rgb[i+ahdTile-1][Green]+
+						m.at(x+1, y+1)-rgb[i+ahdTile+1][Green])/4)
+				}
+				buf.lab[d][i] = ahdLab(rgb[i])
+			}
+		}
+	}
+
+	// homogeneity: number of neighbours closer than the smallest of the
+	// horizontal and vertical spreads, both in lightness and in chroma
+	dir := [4]int{-1, 1, -ahdTile, ahdTile}
+	for y := top + 2; y < rowEnd-2; y++ {
+		for x := left + 2; x < colEnd-2; x++ {
+			i := (y-top)*ahdTile + x - left
+			var ldiff, abdiff [2][4]float32
+			for d := 0; d < 2; d++ {
+				lab := &buf.lab[d]
+				for k, o := range dir {
+					ldiff[d][k] = abs32(lab[i][0] - lab[i+o][0])
+					da := lab[i][1] - lab[i+o][1]
+					db := lab[i][2] - lab[i+o][2]
+					abdiff[d][k] = da*da + db*db
+				}
+			}
+			leps := min32(max32(ldiff[0][0], ldiff[0][1]), max32(ldiff[1][2], ldiff[1][3]))
+			abeps := min32(max32(abdiff[0][0], abdiff[0][1]), max32(abdiff[1][2], abdiff[1][3]))
+			for d := 0; d < 2; d++ {
+				var h uint8
+				for k := range dir {
+					if ldiff[d][k] <= leps && abdiff[d][k] <= abeps {
+						h++
+					}
+				}
+				buf.homo[d][i] = h
+			}
+		}
+	}
+
+	// keep the most homogeneous direction over a 3x3 window
+	rowLast := top + ahdTile - 3
+	if rowLast > height-5 {
+		rowLast = height - 5
+	}
+	colLast := left + ahdTile - 3
+	if colLast > width-5 {
+		colLast = width - 5
+	}
+	for y := top + 3; y < rowLast; y++ {
+		for x := left + 3; x < colLast; x++ {
+			i := (y-top)*ahdTile + x - left
+			var hm [2]int
+			for d := 0; d < 2; d++ {
+				for dy := -ahdTile; dy <= ahdTile; dy += ahdTile {
+					for dx := -1; dx <= 1; dx++ {
+						hm[d] += int(buf.homo[d][i+dy+dx])
+					}
+				}
+			}
+			o := y*out.Stride + 3*x
+			switch {
+			case hm[0] > hm[1]:
+				copy(out.Pix[o:o+3], buf.rgb[0][i][:])
+			case hm[0] < hm[1]:
+				copy(out.Pix[o:o+3], buf.rgb[1][i][:])
+			default:
+				for c := 0; c < 3; c++ {
+					out.Pix[o+c] = (buf.rgb[0][i][c] + buf.rgb[1][i][c]) / 2
+				}
+			}
+		}
+	}
+}
+
+func ahdLab(rgb [3]float32) [3]float32 {
+	var f [3]float32
+	for k := 0; k < 3; k++ {
+		t := ahdXYZ[k][0]*rgb[0] + ahdXYZ[k][1]*rgb[1] + ahdXYZ[k][2]*rgb[2]
+		if t > 0.008856 {
+			f[k] = float32(math.Cbrt(float64(t)))
+		} else {
+			f[k] = 7.787*t + 16.0/116
+		}
+	}
+	return [3]float32{116*f[1] - 16, 500 * (f[0] - f[1]), 200 * (f[1] - f[2])}
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,43 @@
+package demosaic
+
+import "github.com/lpautet/cr2cv/internal/imageutil"
+
+// bilinear averages, for each missing colour, the neighbours of that colour
+// in the surrounding 3x3 window.
+func bilinear(m *Mosaic, out *RGB, workers int) {
+	width := m.Rect.Dx()
+	height := m.Rect.Dy()
+	imageutil.ParallelFor(height, workers, func(y int) {
+		for x := 0; x < width; x++ {
+			own := m.color(x, y)
+			var sum [3]float32
+			var count [3]int
+			for dy := -1; dy <= 1; dy++ {
+				yy := y + dy
+				if yy < 0 || yy >= height {
+					continue
+				}
+				for dx := -1; dx <= 1; dx++ {
+					xx := x + dx
+					if xx < 0 || xx >= width {
+						continue
+					}
+					c := m.color(xx, yy)
+					if c == own {
+						continue
+					}
+					sum[c] += m.at(xx, yy)
+					count[c]++
+				}
+			}
+			i := y*out.Stride + 3*x
+			for c := 0; c < 3; c++ {
+				if Color(c) == own {
+					out.Pix[i+c] = m.at(x, y)
+				} else if count[c] > 0 {
+					out.Pix[i+c] = sum[c] / float32(count[c])
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package demosaic
+
+import (
+	"fmt"
+	"github.com/lpautet/cr2cv/internal/imageutil"
+	"image"
+	"image/color"
+)
+
+type Color uint8
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+// Pattern lists the colours of the 2x2 colour filter tile, left to right and
+// top to bottom, for the tile whose top left corner is at an even x and y.
+type Pattern [4]Color
+
+var RGGB = Pattern{Red, Green, Green, Blue}
+
+func (p Pattern) Color(x, y int) Color {
+	return p[(y&1)<<1|x&1]
+}
+
+// Mosaic is a single plane colour filter array image. Samples are linear,
+// with 0 for black and 1 for the sensor white level.
+type Mosaic struct {
+	Pix     []float32
+	Stride  int
+	Rect    image.Rectangle
+	Pattern Pattern
+}
+
+func NewMosaic(r image.Rectangle, pattern Pattern) *Mosaic {
+	return &Mosaic{
+		Pix:     make([]float32, r.Dx()*r.Dy()),
+		Stride:  r.Dx(),
+		Rect:    r,
+		Pattern: pattern,
+	}
+}
+
+// at and color use coordinates relative to Rect.Min
+func (m *Mosaic) at(x, y int) float32 {
+	return m.Pix[y*m.Stride+x]
+}
+
+func (m *Mosaic) color(x, y int) Color {
+	return m.Pattern.Color(x+m.Rect.Min.X, y+m.Rect.Min.Y)
+}
+
+// RGB is a linear floating point colour image with three samples per pixel.
+// Values are not clamped, 1 being the white level.
+type RGB struct {
+	Pix    []float32
+	Stride int
+	Rect   image.Rectangle
+}
+
+func NewRGB(r image.Rectangle) *RGB {
+	return &RGB{
+		Pix:    make([]float32, 3*r.Dx()*r.Dy()),
+		Stride: 3 * r.Dx(),
+		Rect:   r,
+	}
+}
+
+func (p *RGB) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+func (p *RGB) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *RGB) At(x, y int) color.Color {
+	return p.RGBA64At(x, y)
+}
+
+func (p *RGB) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*3
+}
+
+func (p *RGB) RGBA64At(x, y int) color.RGBA64 {
+	if !(image.Point{X: x, Y: y}.In(p.Rect)) {
+		return color.RGBA64{}
+	}
+	i := p.PixOffset(x, y)
+	return color.RGBA64{R: imageutil.To16(p.Pix[i]), G: imageutil.To16(p.Pix[i+1]), B: imageutil.To16(p.Pix[i+2]), A: 0xffff}
+}
+
+// RGBA64 converts the image to 16 bits per channel, clipping values outside
+// of [0, 1].
+func (p *RGB) RGBA64() *image.RGBA64 {
+	img := image.NewRGBA64(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			img.SetRGBA64(x, y, p.RGBA64At(x, y))
+		}
+	}
+	return img
+}
+
+type Algorithm int
+
+const (
+	Bilinear Algorithm = iota
+	VNG
+	AHD
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case Bilinear:
+		return "bilinear"
+	case VNG:
+		return "vng"
+	case AHD:
+		return "ahd"
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(a))
+}
+
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for _, a := range []Algorithm{Bilinear, VNG, AHD} {
+		if a.String() == name {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("demosaic: unknown algorithm %q", name)
+}
+
+type Options struct {
+	Algorithm Algorithm
+	// Workers is the number of goroutines used, at least one.
+	Workers int
+}
+
+// Demosaic interpolates the two missing colours of every pixel of a Bayer
+// mosaic.
+func Demosaic(m *Mosaic, opts Options) (*RGB, error) {
+	for _, c := range m.Pattern {
+		if c > Blue {
+			return nil, fmt.Errorf("demosaic: invalid pattern %v", m.Pattern)
+		}
+	}
+	out := NewRGB(m.Rect)
+	bilinear(m, out, opts.Workers)
+	switch opts.Algorithm {
+	case Bilinear:
+	case VNG:
+		vng(m, out, opts.Workers)
+	case AHD:
+		ahd(m, out, opts.Workers)
+	default:
+		return nil, fmt.Errorf("demosaic: unknown algorithm %v", opts.Algorithm)
+	}
+	return out, nil
+}
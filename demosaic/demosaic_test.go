@@ -0,0 +1,159 @@
+package demosaic
+
+import (
+	"fmt"
+	"image"
+	"testing"
+)
+
+var algorithms = []Algorithm{Bilinear, VNG, AHD}
+
+// mosaicOf samples the colour of each pixel given by scene through the
+// colour filter.
+func mosaicOf(r image.Rectangle, pattern Pattern, scene func(x, y int) [3]float32) *Mosaic {
+	m := NewMosaic(r, pattern)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			m.Pix[(y-r.Min.Y)*m.Stride+x-r.Min.X] = scene(x, y)[pattern.Color(x, y)]
+		}
+	}
+	return m
+}
+
+// checkScene reports the first pixel of out, among those for which check
+// returns true, differing from scene by more than 1e-5.
+func checkScene(t *testing.T, name string, out *RGB, scene func(x, y int) [3]float32, check func(x, y int) bool) {
+	for y := out.Rect.Min.Y; y < out.Rect.Max.Y; y++ {
+		for x := out.Rect.Min.X; x < out.Rect.Max.X; x++ {
+			if !check(x, y) {
+				continue
+			}
+			want := scene(x, y)
+			i := out.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				if d := out.Pix[i+c] - want[c]; d > 1e-5 || d < -1e-5 {
+					t.Errorf("%s: pixel (%d, %d) is %v, want %v", name, x, y, out.Pix[i:i+3], want)
+					return
+				}
+			}
+		}
+	}
+}
+
+func everywhere(x, y int) bool {
+	return true
+}
+
+func TestDemosaicFlat(t *testing.T) {
+	flat := func(x, y int) [3]float32 {
+		return [3]float32{0.5, 0.3, 0.2}
+	}
+	// larger than an AHD tile in both directions, and not aligned on the
+	// colour filter tile
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 40, 30), image.Rect(1, 3, 600, 520)} {
+		for _, a := range algorithms {
+			for _, workers := range []int{1, 3} {
+				out, err := Demosaic(mosaicOf(r, RGGB, flat), Options{Algorithm: a, Workers: workers})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if out.Rect != r {
+					t.Fatalf("%v: bounds %v, want %v", a, out.Rect, r)
+				}
+				checkScene(t, fmt.Sprintf("%v %v %d workers", a, r, workers), out, flat, everywhere)
+			}
+		}
+	}
+}
+
+func TestDemosaicEdge(t *testing.T) {
+	const width, height = 600, 520
+	left, right := [3]float32{0.6, 0.3, 0.1}, [3]float32{0.1, 0.2, 0.5}
+	// edges at the overlap of the first two AHD tiles, and inside a tile
+	for _, edge := range []int{ahdTile - 4, ahdTile - 1, 100, 301} {
+		vertical := func(x, y int) [3]float32 {
+			if x < edge {
+				return left
+			}
+			return right
+		}
+		horizontal := func(x, y int) [3]float32 {
+			if y < edge {
+				return left
+			}
+			return right
+		}
+		// interpolation windows are at most 5x5, and AHD picks its
+		// direction over a further 3x3 window
+		farX := func(x, y int) bool { return x < edge-4 || x >= edge+4 }
+		farY := func(x, y int) bool { return y < edge-4 || y >= edge+4 }
+		for _, a := range algorithms {
+			r := image.Rect(0, 0, width, height)
+			out, err := Demosaic(mosaicOf(r, RGGB, vertical), Options{Algorithm: a, Workers: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkScene(t, fmt.Sprintf("%v, vertical edge at %d", a, edge), out, vertical, farX)
+			out, err = Demosaic(mosaicOf(r, RGGB, horizontal), Options{Algorithm: a, Workers: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkScene(t, fmt.Sprintf("%v, horizontal edge at %d", a, edge), out, horizontal, farY)
+		}
+	}
+}
+
+func TestDemosaicSmallSizes(t *testing.T) {
+	flat := func(x, y int) [3]float32 {
+		return [3]float32{0.25, 0.5, 0.75}
+	}
+	sizes := [][2]int{{1, 1}, {1, 7}, {2, 2}, {3, 3}, {4, 5}, {5, 5}, {6, 6}, {7, 9}, {9, 7}, {13, 11}, {3, 300}, {300, 3}, {ahdTile + 1, 7}, {ahdTile - 5, ahdTile + 5}}
+	for _, size := range sizes {
+		r := image.Rect(1, 0, 1+size[0], size[1])
+		for _, a := range algorithms {
+			out, err := Demosaic(mosaicOf(r, RGGB, flat), Options{Algorithm: a, Workers: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Rect != r {
+				t.Errorf("%v %dx%d: bounds %v, want %v", a, size[0], size[1], out.Rect, r)
+			}
+			// every colour has a neighbour from 2x2 on
+			if size[0] >= 2 && size[1] >= 2 {
+				checkScene(t, fmt.Sprintf("%v %dx%d", a, size[0], size[1]), out, flat, everywhere)
+			}
+		}
+	}
+}
+
+// The AHD value of a pixel does not depend on the tile it falls in: tiles
+// moved by cropping the mosaic give the same interior pixels.
+func TestAHDTiling(t *testing.T) {
+	scene := func(x, y int) [3]float32 {
+		v := float32((x*37+y*91)%101) / 101
+		return [3]float32{v, float32((x*x+y)%17) / 17, 1 - v}
+	}
+	full := mosaicOf(image.Rect(0, 0, 700, 600), RGGB, scene)
+	want, err := Demosaic(full, Options{Algorithm: AHD, Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, crop := range []image.Rectangle{image.Rect(100, 37, 700, 600), image.Rect(123, 200, 611, 599)} {
+		m := mosaicOf(crop, RGGB, scene)
+		out, err := Demosaic(m, Options{Algorithm: AHD, Workers: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		inner := image.Rect(crop.Min.X+5, crop.Min.Y+5, crop.Max.X-5, crop.Max.Y-5)
+		for y := inner.Min.Y; y < inner.Max.Y; y++ {
+			for x := inner.Min.X; x < inner.Max.X; x++ {
+				i, j := out.PixOffset(x, y), want.PixOffset(x, y)
+				for c := 0; c < 3; c++ {
+					if d := out.Pix[i+c] - want.Pix[j+c]; d > 1e-5 || d < -1e-5 {
+						t.Fatalf("crop %v: pixel (%d, %d) is %v, want %v", crop, x, y, out.Pix[i:i+3], want.Pix[j:j+3])
+					}
+				}
+			}
+		}
+	}
+}
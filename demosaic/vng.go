@@ -0,0 +1,125 @@
+package demosaic
+
+import "github.com/lpautet/cr2cv/internal/imageutil"
+
+// Threshold of the gradients kept by VNG: k1*min + k2*(max-min)
+const (
+	vngK1 = 1.5
+	vngK2 = 0.5
+)
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// vng implements the Variable Number of Gradients interpolation of Chang,
+// Cheung and Pang. Eight gradients are measured in the 5x5 neighbourhood of
+// each pixel; the colour differences of the smoothest directions are averaged.
+// The two pixel wide border keeps its bilinear values.
+func vng(m *Mosaic, out *RGB, workers int) {
+	width := m.Rect.Dx()
+	height := m.Rect.Dy()
+	if width < 5 || height < 5 {
+		return
+	}
+	imageutil.ParallelFor(height-4, workers, func(row int) {
+		y := row + 2
+		// p[1..25] is the 5x5 neighbourhood, row by row, p[13] being the pixel
+		var p [26]float32
+		var grad [8]float32
+		var sums [8][3]float32
+		for x := 2; x < width-2; x++ {
+			k := 1
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					p[k] = m.at(x+dx, y+dy)
+					k++
+				}
+			}
+			own := m.color(x, y)
+
+			// N, E, S and W gradients are the same for every pixel colour
+			grad[0] = abs32(p[8]-p[18]) + abs32(p[3]-p[13]) + (abs32(p[7]-p[17])+abs32(p[9]-p[19])+abs32(p[2]-p[12])+abs32(p[4]-p[14]))/2
+			grad[1] = abs32(p[14]-p[12]) + abs32(p[15]-p[13]) + (abs32(p[9]-p[7])+abs32(p[19]-p[17])+abs32(p[10]-p[8])+abs32(p[20]-p[18]))/2
+			grad[2] = abs32(p[18]-p[8]) + abs32(p[23]-p[13]) + (abs32(p[19]-p[9])+abs32(p[17]-p[7])+abs32(p[24]-p[14])+abs32(p[22]-p[12]))/2
+			grad[3] = abs32(p[12]-p[14]) + abs32(p[11]-p[13]) + (abs32(p[17]-p[19])+abs32(p[7]-p[9])+abs32(p[16]-p[18])+abs32(p[6]-p[8]))/2
+
+			// sums[d] holds the estimates of direction d for
+			// [own colour, horizontal/green neighbour colour, the other colour]
+			var horizontal, other Color
+			if own == Green {
+				horizontal = m.color(x+1, y)
+				other = m.color(x, y+1)
+
+				grad[4] = abs32(p[9]-p[17]) + abs32(p[5]-p[13]) + abs32(p[4]-p[12]) + abs32(p[10]-p[18])
+				grad[5] = abs32(p[19]-p[7]) + abs32(p[25]-p[13]) + abs32(p[20]-p[8]) + abs32(p[24]-p[12])
+				grad[6] = abs32(p[7]-p[19]) + abs32(p[1]-p[13]) + abs32(p[6]-p[18]) + abs32(p[2]-p[14])
+				grad[7] = abs32(p[17]-p[9]) + abs32(p[21]-p[13]) + abs32(p[22]-p[14]) + abs32(p[16]-p[8])
+
+				sums[0] = [3]float32{(p[3] + p[13]) / 2, (p[2] + p[4] + p[12] + p[14]) / 4, p[8]}
+				sums[1] = [3]float32{(p[13] + p[15]) / 2, p[14], (p[8] + p[10] + p[18] + p[20]) / 4}
+				sums[2] = [3]float32{(p[13] + p[23]) / 2, (p[12] + p[14] + p[22] + p[24]) / 4, p[18]}
+				sums[3] = [3]float32{(p[11] + p[13]) / 2, p[12], (p[6] + p[8] + p[16] + p[18]) / 4}
+				sums[4] = [3]float32{p[9], (p[4] + p[14]) / 2, (p[8] + p[10]) / 2}
+				sums[5] = [3]float32{p[19], (p[14] + p[24]) / 2, (p[18] + p[20]) / 2}
+				sums[6] = [3]float32{p[7], (p[2] + p[12]) / 2, (p[6] + p[8]) / 2}
+				sums[7] = [3]float32{p[17], (p[12] + p[22]) / 2, (p[16] + p[18]) / 2}
+			} else {
+				horizontal = Green
+				other = m.color(x+1, y+1)
+
+				grad[4] = abs32(p[9]-p[17]) + abs32(p[5]-p[13]) + (abs32(p[8]-p[12])+abs32(p[14]-p[18])+abs32(p[4]-p[8])+abs32(p[10]-p[14]))/2
+				grad[5] = abs32(p[19]-p[7]) + abs32(p[25]-p[13]) + (abs32(p[14]-p[8])+abs32(p[18]-p[12])+abs32(p[20]-p[14])+abs32(p[24]-p[18]))/2
+				grad[6] = abs32(p[7]-p[19]) + abs32(p[1]-p[13]) + (abs32(p[12]-p[18])+abs32(p[8]-p[14])+abs32(p[6]-p[12])+abs32(p[2]-p[8]))/2
+				grad[7] = abs32(p[17]-p[9]) + abs32(p[21]-p[13]) + (abs32(p[18]-p[14])+abs32(p[12]-p[8])+abs32(p[22]-p[18])+abs32(p[16]-p[12]))/2
+
+				sums[0] = [3]float32{(p[3] + p[13]) / 2, p[8], (p[7] + p[9]) / 2}
+				sums[1] = [3]float32{(p[13] + p[15]) / 2, p[14], (p[9] + p[19]) / 2}
+				sums[2] = [3]float32{(p[13] + p[23]) / 2, p[18], (p[17] + p[19]) / 2}
+				sums[3] = [3]float32{(p[11] + p[13]) / 2, p[12], (p[7] + p[17]) / 2}
+				sums[4] = [3]float32{(p[5] + p[13]) / 2, (p[4] + p[8] + p[10] + p[14]) / 4, p[9]}
+				sums[5] = [3]float32{(p[13] + p[25]) / 2, (p[14] + p[18] + p[20] + p[24]) / 4, p[19]}
+				sums[6] = [3]float32{(p[1] + p[13]) / 2, (p[2] + p[6] + p[8] + p[12]) / 4, p[7]}
+				sums[7] = [3]float32{(p[13] + p[21]) / 2, (p[12] + p[16] + p[18] + p[22]) / 4, p[17]}
+			}
+
+			min, max := grad[0], grad[0]
+			for _, g := range grad[1:] {
+				if g < min {
+					min = g
+				}
+				if g > max {
+					max = g
+				}
+			}
+			threshold := vngK1*min + vngK2*(max-min)
+
+			var total [3]float32
+			n := 0
+			for d, g := range grad {
+				if g <= threshold {
+					total[0] += sums[d][0]
+					total[1] += sums[d][1]
+					total[2] += sums[d][2]
+					n++
+				}
+			}
+
+			i := y*out.Stride + 3*x
+			value := p[13]
+			out.Pix[i+int(own)] = value
+			out.Pix[i+int(horizontal)] = clampPositive(value + (total[1]-total[0])/float32(n))
+			out.Pix[i+int(other)] = clampPositive(value + (total[2]-total[0])/float32(n))
+		}
+	})
+}
+
+func clampPositive(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
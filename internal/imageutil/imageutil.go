@@ -0,0 +1,49 @@
+// Package imageutil holds the helpers shared by the image processing
+// packages.
+package imageutil
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ParallelFor calls fn for every index in [0, n) from at most workers
+// goroutines.
+func ParallelFor(n int, workers int, fn func(i int)) {
+	if workers <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	if workers > n {
+		workers = n
+	}
+	var wg sync.WaitGroup
+	var next int64 = -1
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// To16 converts a value in [0, 1] to 16 bits, clipping values out of range.
+func To16(v float32) uint16 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 0xffff
+	}
+	return uint16(v*0xffff + 0.5)
+}
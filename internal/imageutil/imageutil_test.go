@@ -0,0 +1,33 @@
+package imageutil
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelFor(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 16} {
+		for _, n := range []int{0, 1, 7, 100} {
+			counts := make([]int32, n)
+			ParallelFor(n, workers, func(i int) {
+				atomic.AddInt32(&counts[i], 1)
+			})
+			for i, count := range counts {
+				if count != 1 {
+					t.Errorf("workers %d, n %d: index %d called %d times", workers, n, i, count)
+				}
+			}
+		}
+	}
+}
+
+func TestTo16(t *testing.T) {
+	for _, c := range []struct {
+		v    float32
+		want uint16
+	}{{-1, 0}, {0, 0}, {0.5, 0x8000}, {1, 0xffff}, {2, 0xffff}} {
+		if got := To16(c.v); got != c.want {
+			t.Errorf("To16(%v) = %#x, want %#x", c.v, got, c.want)
+		}
+	}
+}
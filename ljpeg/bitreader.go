@@ -0,0 +1,100 @@
+package ljpeg
+
+import (
+	"fmt"
+)
+
+// bitReader reads the entropy coded segment of a lossless JPEG scan. Each
+// decode owns its bitReader, so several images can be decoded concurrently.
+type bitReader struct {
+	data   []byte
+	pos    int
+	val    uint64
+	len    uint8
+	marker bool
+	fake   uint8
+}
+
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{data: data}
+}
+
+// fill tops the queue up to at least 57 bits, un-stuffing 0xff00 sequences.
+// Once a marker (or the end of the data) is reached, zero bits are queued
+// instead and accounted for in fake.
+func (br *bitReader) fill() {
+	for br.len <= 56 {
+		readByte := byte(0)
+		if !br.marker && br.pos < len(br.data) {
+			readByte = br.data[br.pos]
+			if readByte == 0xff {
+				if br.pos+1 >= len(br.data) || br.data[br.pos+1] != 0x00 {
+					br.marker = true
+					continue
+				}
+				br.pos++
+			}
+			br.pos++
+		} else {
+			br.marker = true
+			br.fake += 8
+		}
+		br.val |= uint64(readByte) << (56 - br.len)
+		br.len += 8
+	}
+}
+
+func (br *bitReader) consume(len uint8) {
+	br.len -= len
+	br.val <<= len
+}
+
+func (br *bitReader) check() error {
+	if br.len < br.fake {
+		return fmt.Errorf("unexpected end of image data at byte %d", br.pos)
+	}
+	return nil
+}
+
+func (br *bitReader) readBits(len uint8) (uint16, error) {
+	if len == 0 {
+		return 0, nil
+	}
+	if len > br.len {
+		br.fill()
+	}
+	// Shift the requested number of bits down to the other end
+	output := uint16(br.val >> (64 - len))
+	br.consume(len)
+	return output, br.check()
+}
+
+// readDiff decodes one difference value: a Huffman coded bit length followed
+// by that many additional bits.
+func (br *bitReader) readDiff(table *huffmanTable) (int, error) {
+	diffCodeLen, err := table.decode(br)
+	if err != nil {
+		return 0, err
+	}
+	if err := br.check(); err != nil {
+		return 0, err
+	}
+	switch {
+	case diffCodeLen == 0:
+		return 0, nil
+	case diffCodeLen == 16:
+		return 32768, nil
+	case diffCodeLen > 16:
+		return 0, fmt.Errorf("invalid difference length %d", diffCodeLen)
+	}
+	diffCode, err := br.readBits(diffCodeLen)
+	if err != nil {
+		return 0, err
+	}
+	if diffCode&(1<<(diffCodeLen-1)) != 0 {
+		// positive diff
+		return int(diffCode), nil
+	}
+	// negative diff
+	return int(diffCode) - (1 << diffCodeLen) + 1, nil
+}
@@ -0,0 +1,342 @@
+package ljpeg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"github.com/lpautet/cr2cv/internal/imageutil"
+	"io"
+	"sort"
+)
+
+// Encode writes img as a lossless JPEG stream. Precision, Width, Height,
+// Components (ID, H and V), Predictor, PointTransform, RestartInterval and
+// Pix are used; the MCU layout is derived from them and every component gets
+// its own optimal Huffman table. A nil opts encodes with the default options.
+func Encode(w io.Writer, img *Image, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	frame, err := encodedFrame(img)
+	if err != nil {
+		return err
+	}
+	sc := newScan(frame, [4]*huffmanTable{}, opts)
+
+	// samples reduced by the point transform
+	pix := frame.Pix
+	if frame.PointTransform > 0 {
+		pix = make([]uint16, len(frame.Pix))
+		for i, v := range frame.Pix {
+			pix[i] = v >> frame.PointTransform
+		}
+	}
+
+	// differences to the predictions, and their bit lengths (SSSS)
+	diffs := make([]uint16, len(pix))
+	lengths := make([]uint8, len(pix))
+	imageutil.ParallelFor(sc.intervals(), opts.Workers, func(interval int) {
+		first, last := sc.interval(interval)
+		for mcu := first; mcu < last; mcu++ {
+			for s := 0; s < sc.mcuSize; s++ {
+				pos := mcu*sc.mcuSize + s
+				diff := (int(pix[pos]) - sc.prediction(pix, mcu, s, first)) & 0xffff
+				diffs[pos] = uint16(diff)
+				lengths[pos] = diffLength(diff)
+			}
+		}
+	})
+
+	var freqs [4][17]int
+	for pos, length := range lengths {
+		freqs[sc.components[pos%sc.mcuSize]][length]++
+	}
+	var tables [4]huffmanCode
+	for c := range frame.Components {
+		tables[c] = newHuffmanCode(freqs[c])
+	}
+
+	bw := bufio.NewWriter(w)
+	writeMarker(bw, markerSOI)
+	writeDHT(bw, tables[:len(frame.Components)])
+	if frame.RestartInterval > 0 {
+		writeMarker(bw, markerDRI)
+		writeUint16(bw, 4, uint16(frame.RestartInterval))
+	}
+	writeSOF3(bw, frame)
+	writeSOS(bw, frame)
+
+	bits := &bitWriter{w: bw}
+	for interval := 0; interval < sc.intervals(); interval++ {
+		if interval > 0 {
+			bits.flush()
+			writeMarker(bw, markerRST0+uint16((interval-1)%8))
+		}
+		first, last := sc.interval(interval)
+		for pos := first * sc.mcuSize; pos < last*sc.mcuSize; pos++ {
+			table := &tables[sc.components[pos%sc.mcuSize]]
+			length := lengths[pos]
+			bits.write(uint32(table.codes[length]), table.lengths[length])
+			if length == 0 || length == 16 {
+				continue
+			}
+			// negative differences are written as diff - 1 on length bits
+			diff := uint32(diffs[pos])
+			if diff >= 0x8000 {
+				diff--
+			}
+			bits.write(diff&(1<<length-1), length)
+		}
+	}
+	bits.flush()
+	writeMarker(bw, markerEOI)
+	return bw.Flush()
+}
+
+// encodedFrame validates img and returns a copy with the MCU layout and the
+// Huffman table of each component set.
+func encodedFrame(img *Image) (*Image, error) {
+	if img.Precision < 2 || img.Precision > 16 {
+		return nil, fmt.Errorf("ljpeg: unsupported precision: %d bits", img.Precision)
+	}
+	if img.Predictor < 1 || img.Predictor > 7 {
+		return nil, fmt.Errorf("ljpeg: unsupported predictor %d", img.Predictor)
+	}
+	if img.PointTransform >= img.Precision {
+		return nil, fmt.Errorf("ljpeg: point transform %d too large for %d bits", img.PointTransform, img.Precision)
+	}
+	if img.Width <= 0 || img.Width > 0xffff || img.Height <= 0 || img.Height > 0xffff {
+		return nil, fmt.Errorf("ljpeg: unsupported size %dx%d", img.Width, img.Height)
+	}
+	if len(img.Components) == 0 || len(img.Components) > 4 {
+		return nil, fmt.Errorf("ljpeg: unsupported number of components %d", len(img.Components))
+	}
+	if img.RestartInterval < 0 || img.RestartInterval > 0xffff {
+		return nil, fmt.Errorf("ljpeg: invalid restart interval %d", img.RestartInterval)
+	}
+	frame := *img
+	frame.Components = make([]Component, len(img.Components))
+	hMax, vMax := 1, 1
+	ids := make(map[uint8]bool)
+	for c, comp := range img.Components {
+		if ids[comp.ID] {
+			return nil, fmt.Errorf("ljpeg: duplicate component %d", comp.ID)
+		}
+		ids[comp.ID] = true
+		if len(img.Components) == 1 {
+			comp.H, comp.V = 1, 1
+		}
+		if comp.H < 1 || comp.H > 4 || comp.V < 1 || comp.V > 4 {
+			return nil, fmt.Errorf("ljpeg: invalid sampling %dx%d for component %d", comp.H, comp.V, comp.ID)
+		}
+		if comp.H > hMax {
+			hMax = comp.H
+		}
+		if comp.V > vMax {
+			vMax = comp.V
+		}
+		comp.Table = uint8(c)
+		frame.Components[c] = comp
+	}
+	frame.MCUsPerLine = (frame.Width + hMax - 1) / hMax
+	frame.MCULines = (frame.Height + vMax - 1) / vMax
+	if expected := frame.MCUsPerLine * frame.MCULines * frame.MCUSize(); len(frame.Pix) != expected {
+		return nil, fmt.Errorf("ljpeg: %d samples, expected %d", len(frame.Pix), expected)
+	}
+	for i, v := range frame.Pix {
+		if int(v) >= 1<<frame.Precision {
+			return nil, fmt.Errorf("ljpeg: sample %d does not fit in %d bits: %d", i, frame.Precision, v)
+		}
+	}
+	return &frame, nil
+}
+
+// diffLength returns the number of bits of a difference modulo 2^16, 16
+// standing for 32768.
+func diffLength(diff int) uint8 {
+	if diff >= 0x8000 {
+		diff = 0x10000 - diff
+	}
+	length := uint8(0)
+	for diff != 0 {
+		diff >>= 1
+		length++
+	}
+	return length
+}
+
+// huffmanCode is the code of every difference length of an encoding table.
+type huffmanCode struct {
+	counts  [16]uint8
+	values  []uint8
+	codes   [17]uint16
+	lengths [17]uint8
+}
+
+// newHuffmanCode builds the optimal code lengths of the frequencies, limited
+// to 16 bits, following ITU T.81 K.2. A reserved symbol keeps any code from
+// being all ones.
+func newHuffmanCode(freqs [17]int) huffmanCode {
+	const reserved = 17
+	var freq [reserved + 1]int
+	copy(freq[:], freqs[:])
+	freq[reserved] = 1
+	var codeSize [reserved + 1]int
+	var others [reserved + 1]int
+	for i := range others {
+		others[i] = -1
+	}
+	// least frequent symbol, the largest one on ties, other than skip
+	least := func(skip int) int {
+		v := -1
+		for i := range freq {
+			if i != skip && freq[i] > 0 && (v < 0 || freq[i] <= freq[v]) {
+				v = i
+			}
+		}
+		return v
+	}
+	for {
+		v1 := least(-1)
+		v2 := least(v1)
+		if v2 < 0 {
+			break
+		}
+		freq[v1] += freq[v2]
+		freq[v2] = 0
+		for codeSize[v1]++; others[v1] >= 0; codeSize[v1]++ {
+			v1 = others[v1]
+		}
+		others[v1] = v2
+		for codeSize[v2]++; others[v2] >= 0; codeSize[v2]++ {
+			v2 = others[v2]
+		}
+	}
+
+	var bits [33]int
+	for _, size := range codeSize {
+		if size > 0 {
+			bits[size]++
+		}
+	}
+	for i := 32; i > 16; i-- {
+		for bits[i] > 0 {
+			j := i - 2
+			for bits[j] == 0 {
+				j--
+			}
+			bits[i] -= 2
+			bits[i-1]++
+			bits[j+1] += 2
+			bits[j]--
+		}
+	}
+	// drop the reserved symbol, the last of the longest codes
+	i := 16
+	for bits[i] == 0 {
+		i--
+	}
+	bits[i]--
+
+	symbols := make([]int, 0, reserved)
+	for s := 0; s < reserved; s++ {
+		if codeSize[s] > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.SliceStable(symbols, func(a, b int) bool {
+		return codeSize[symbols[a]] < codeSize[symbols[b]]
+	})
+
+	var hc huffmanCode
+	code := uint16(0)
+	k := 0
+	for l := 1; l <= 16; l++ {
+		hc.counts[l-1] = uint8(bits[l])
+		for n := 0; n < bits[l]; n++ {
+			s := symbols[k]
+			hc.values = append(hc.values, uint8(s))
+			hc.codes[s] = code
+			hc.lengths[s] = uint8(l)
+			code++
+			k++
+		}
+		code <<= 1
+	}
+	return hc
+}
+
+// bitWriter writes entropy coded data, stuffing a zero byte after every 0xff.
+type bitWriter struct {
+	w   *bufio.Writer
+	acc uint64
+	len uint8
+}
+
+func (bw *bitWriter) write(bits uint32, length uint8) {
+	bw.acc = bw.acc<<length | uint64(bits)
+	bw.len += length
+	for bw.len >= 8 {
+		b := byte(bw.acc >> (bw.len - 8))
+		bw.w.WriteByte(b)
+		if b == 0xff {
+			bw.w.WriteByte(0)
+		}
+		bw.len -= 8
+	}
+}
+
+// flush pads the last byte with one bits.
+func (bw *bitWriter) flush() {
+	if bw.len > 0 {
+		bw.write(1<<(8-bw.len)-1, 8-bw.len)
+	}
+	bw.acc = 0
+}
+
+func writeMarker(w *bufio.Writer, marker uint16) {
+	writeUint16(w, marker)
+}
+
+func writeUint16(w *bufio.Writer, values ...uint16) {
+	for _, v := range values {
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], v)
+		w.Write(b[:])
+	}
+}
+
+func writeDHT(w *bufio.Writer, tables []huffmanCode) {
+	length := 2
+	for _, t := range tables {
+		length += 17 + len(t.values)
+	}
+	writeMarker(w, markerDHT)
+	writeUint16(w, uint16(length))
+	for c, t := range tables {
+		w.WriteByte(byte(c))
+		w.Write(t.counts[:])
+		w.Write(t.values)
+	}
+}
+
+func writeSOF3(w *bufio.Writer, img *Image) {
+	writeMarker(w, markerSOF3)
+	writeUint16(w, uint16(8+3*len(img.Components)))
+	w.WriteByte(img.Precision)
+	writeUint16(w, uint16(img.Height), uint16(img.Width))
+	w.WriteByte(byte(len(img.Components)))
+	for _, c := range img.Components {
+		w.Write([]byte{c.ID, byte(c.H<<4 | c.V), 0})
+	}
+}
+
+func writeSOS(w *bufio.Writer, img *Image) {
+	writeMarker(w, markerSOS)
+	writeUint16(w, uint16(6+2*len(img.Components)))
+	w.WriteByte(byte(len(img.Components)))
+	for _, c := range img.Components {
+		w.Write([]byte{c.ID, c.Table << 4})
+	}
+	w.Write([]byte{img.Predictor, 0, img.PointTransform})
+}
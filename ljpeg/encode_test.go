@@ -0,0 +1,259 @@
+package ljpeg
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// testFrame returns a frame of the given components, with sample i of Pix
+// set to sample(i). The sampling factors of components are kept, the first
+// one giving the MCU width and height.
+func testFrame(precision uint8, width int, height int, components []Component, sample func(i int) uint16) *Image {
+	img := &Image{
+		Precision:  precision,
+		Width:      width,
+		Height:     height,
+		Components: components,
+		Predictor:  1,
+	}
+	hMax, vMax := 1, 1
+	if len(components) > 1 {
+		for _, c := range components {
+			if c.H > hMax {
+				hMax = c.H
+			}
+			if c.V > vMax {
+				vMax = c.V
+			}
+		}
+	}
+	mcus := (width + hMax - 1) / hMax * ((height + vMax - 1) / vMax)
+	img.Pix = make([]uint16, mcus*img.MCUSize())
+	for i := range img.Pix {
+		img.Pix[i] = sample(i)
+	}
+	return img
+}
+
+// noise returns random samples of the given precision.
+func noise(precision uint8, seed int64) func(int) uint16 {
+	r := rand.New(rand.NewSource(seed))
+	return func(int) uint16 {
+		return uint16(r.Intn(1 << precision))
+	}
+}
+
+// smooth returns samples of the given precision varying slowly, as in
+// photographs, so that the short codes get used.
+func smooth(precision uint8, seed int64) func(int) uint16 {
+	r := rand.New(rand.NewSource(seed))
+	v := 1 << (precision - 1)
+	return func(int) uint16 {
+		v += r.Intn(9) - 4
+		if v < 0 {
+			v = 0
+		} else if v >= 1<<precision {
+			v = 1<<precision - 1
+		}
+		return uint16(v)
+	}
+}
+
+func flat(v uint16) func(int) uint16 {
+	return func(int) uint16 {
+		return v
+	}
+}
+
+var (
+	oneComponent   = []Component{{ID: 1, H: 1, V: 1}}
+	twoComponents  = []Component{{ID: 1, H: 1, V: 1}, {ID: 2, H: 1, V: 1}}
+	fourComponents = []Component{{ID: 1, H: 1, V: 1}, {ID: 2, H: 1, V: 1}, {ID: 3, H: 1, V: 1}, {ID: 4, H: 1, V: 1}}
+	// Canon sRAW and mRAW: two or four Y samples followed by Cb and Cr
+	sraw2x1 = []Component{{ID: 1, H: 2, V: 1}, {ID: 2, H: 1, V: 1}, {ID: 3, H: 1, V: 1}}
+	sraw2x2 = []Component{{ID: 1, H: 2, V: 2}, {ID: 2, H: 1, V: 1}, {ID: 3, H: 1, V: 1}}
+)
+
+// roundTrip encodes img, decodes the stream with the same options and checks
+// that the frame and its samples, reduced by the point transform, are back.
+func roundTrip(t *testing.T, name string, img *Image, opts *Options) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, opts); err != nil {
+		t.Errorf("%s: Encode: %v", name, err)
+		return
+	}
+	got, err := Decode(bytes.NewReader(buf.Bytes()), opts)
+	if err != nil {
+		t.Errorf("%s: Decode: %v", name, err)
+		return
+	}
+	if got.Precision != img.Precision || got.Width != img.Width || got.Height != img.Height ||
+		got.Predictor != img.Predictor || got.PointTransform != img.PointTransform || got.RestartInterval != img.RestartInterval {
+		t.Errorf("%s: decoded %d bits %dx%d predictor %d point transform %d restart interval %d, want %d bits %dx%d predictor %d point transform %d restart interval %d",
+			name, got.Precision, got.Width, got.Height, got.Predictor, got.PointTransform, got.RestartInterval,
+			img.Precision, img.Width, img.Height, img.Predictor, img.PointTransform, img.RestartInterval)
+		return
+	}
+	if len(got.Components) != len(img.Components) {
+		t.Errorf("%s: decoded %d components, want %d", name, len(got.Components), len(img.Components))
+		return
+	}
+	for c, comp := range got.Components {
+		want := img.Components[c]
+		if len(img.Components) == 1 {
+			want.H, want.V = 1, 1
+		}
+		if comp.ID != want.ID || comp.H != want.H || comp.V != want.V {
+			t.Errorf("%s: component %d decoded as %d %dx%d, want %d %dx%d", name, c, comp.ID, comp.H, comp.V, want.ID, want.H, want.V)
+			return
+		}
+	}
+	if len(got.Pix) != len(img.Pix) {
+		t.Errorf("%s: decoded %d samples, want %d", name, len(got.Pix), len(img.Pix))
+		return
+	}
+	for i, v := range img.Pix {
+		want := v >> img.PointTransform << img.PointTransform
+		if got.Pix[i] != want {
+			t.Errorf("%s: sample %d decoded as %d, want %d", name, i, got.Pix[i], want)
+			return
+		}
+	}
+}
+
+func TestEncodePrecisions(t *testing.T) {
+	for precision := uint8(2); precision <= 16; precision++ {
+		for _, components := range [][]Component{oneComponent, twoComponents} {
+			img := testFrame(precision, 37, 23, components, noise(precision, int64(precision)))
+			roundTrip(t, fmt.Sprintf("%d bits, %d components, noise", precision, len(components)), img, nil)
+			img = testFrame(precision, 37, 23, components, smooth(precision, int64(precision)))
+			roundTrip(t, fmt.Sprintf("%d bits, %d components, smooth", precision, len(components)), img, nil)
+		}
+	}
+}
+
+func TestEncodePredictors(t *testing.T) {
+	for predictor := uint8(1); predictor <= 7; predictor++ {
+		for _, components := range [][]Component{oneComponent, twoComponents, fourComponents} {
+			for _, restartInterval := range []int{0, 5} {
+				img := testFrame(14, 29, 17, components, smooth(14, int64(predictor)))
+				img.Predictor = predictor
+				img.RestartInterval = restartInterval
+				name := fmt.Sprintf("predictor %d, %d components, restart interval %d", predictor, len(components), restartInterval)
+				roundTrip(t, name, img, nil)
+			}
+		}
+	}
+}
+
+func TestEncodeRestartIntervals(t *testing.T) {
+	const width, height = 31, 13
+	// one MCU, a fraction of a line, a line, several lines, a last interval
+	// cut short, and more MCUs than the frame holds
+	for _, restartInterval := range []int{1, 7, width, 3 * width, 2*width + 5, width*height - 1, width * height, 10000} {
+		for _, workers := range []int{1, 4} {
+			img := testFrame(12, width, height, twoComponents, noise(12, int64(restartInterval)))
+			img.Predictor = 4
+			img.RestartInterval = restartInterval
+			roundTrip(t, fmt.Sprintf("restart interval %d, %d workers", restartInterval, workers), img, &Options{Workers: workers})
+		}
+	}
+}
+
+func TestEncodePointTransform(t *testing.T) {
+	for _, precision := range []uint8{8, 14, 16} {
+		for pt := uint8(1); pt < precision; pt++ {
+			img := testFrame(precision, 19, 11, twoComponents, noise(precision, int64(pt)))
+			img.PointTransform = pt
+			roundTrip(t, fmt.Sprintf("%d bits, point transform %d", precision, pt), img, nil)
+		}
+	}
+}
+
+func TestEncodeSRAW(t *testing.T) {
+	for _, sampling := range []struct {
+		name       string
+		components []Component
+	}{
+		{"2x1", sraw2x1},
+		{"2x2", sraw2x2},
+	} {
+		for _, canon := range []bool{false, true} {
+			for predictor := uint8(1); predictor <= 7; predictor++ {
+				// odd sizes leave MCUs partly outside of the frame
+				for _, size := range [][2]int{{40, 20}, {33, 17}} {
+					for _, restartInterval := range []int{0, 3} {
+						img := testFrame(15, size[0], size[1], sampling.components, smooth(15, int64(predictor)))
+						img.Predictor = predictor
+						img.RestartInterval = restartInterval
+						name := fmt.Sprintf("sRAW %s, Canon %v, predictor %d, %dx%d, restart interval %d",
+							sampling.name, canon, predictor, size[0], size[1], restartInterval)
+						roundTrip(t, name, img, &Options{CanonSRAW: canon})
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeFlat(t *testing.T) {
+	for _, precision := range []uint8{2, 8, 12, 14, 16} {
+		max := uint16(1<<precision - 1)
+		for _, v := range []uint16{0, 1, 1 << (precision - 1), max} {
+			for _, components := range [][]Component{oneComponent, fourComponents, sraw2x2} {
+				img := testFrame(precision, 16, 8, components, flat(v))
+				roundTrip(t, fmt.Sprintf("%d bits, %d components, flat %d", precision, len(components), v), img, nil)
+			}
+		}
+	}
+}
+
+func TestEncodeExtremes(t *testing.T) {
+	patterns := []struct {
+		name   string
+		sample func(int) uint16
+	}{
+		// differences of ±0xffff, modulo 2^16 a length of 1
+		{"0 and 0xffff", func(i int) uint16 { return uint16(i%2) * 0xffff }},
+		// differences of 0x8000, the only one of length 16
+		{"0 and 0x8000", func(i int) uint16 { return uint16(i%2) * 0x8000 }},
+		{"0, 0xffff and 0x8000", func(i int) uint16 { return []uint16{0, 0xffff, 0x8000, 0x7fff}[i%4] }},
+		{"0xffff", flat(0xffff)},
+		{"0", flat(0)},
+	}
+	for _, pattern := range patterns {
+		for predictor := uint8(1); predictor <= 7; predictor++ {
+			for _, components := range [][]Component{oneComponent, twoComponents, sraw2x1} {
+				img := testFrame(16, 21, 9, components, pattern.sample)
+				img.Predictor = predictor
+				name := fmt.Sprintf("%s, predictor %d, %d components", pattern.name, predictor, len(components))
+				roundTrip(t, name, img, nil)
+			}
+		}
+	}
+}
+
+func TestEncodeRejectsInvalidFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(img *Image)
+	}{
+		{"precision 1", func(img *Image) { img.Precision = 1 }},
+		{"precision 17", func(img *Image) { img.Precision = 17 }},
+		{"predictor 0", func(img *Image) { img.Predictor = 0 }},
+		{"predictor 8", func(img *Image) { img.Predictor = 8 }},
+		{"point transform", func(img *Image) { img.PointTransform = img.Precision }},
+		{"no component", func(img *Image) { img.Components = nil }},
+		{"too many samples", func(img *Image) { img.Pix = append(img.Pix, 0) }},
+		{"sample too large", func(img *Image) { img.Pix[3] = 1 << img.Precision }},
+	}
+	for _, test := range tests {
+		img := testFrame(12, 8, 8, twoComponents, flat(0))
+		test.modify(img)
+		if err := Encode(&bytes.Buffer{}, img, nil); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ljpeg
+
+import (
+	"fmt"
+)
+
+// Number of bits resolved by a single lookup; longer codes fall back to the
+// canonical maxCode/valPtr search of ITU T.81 F.2.2.3.
+const huffmanLookupBits = 9
+
+type huffmanTable struct {
+	// (code length << 8) | value for every code of at most huffmanLookupBits
+	// bits, indexed by the next huffmanLookupBits bits of the stream
+	lookup  [1 << huffmanLookupBits]uint16
+	maxCode [17]int32
+	minCode [17]int32
+	valPtr  [17]int32
+	values  []uint8
+}
+
+func newHuffmanTable(counts [16]uint8, values []uint8) (*huffmanTable, error) {
+	t := &huffmanTable{values: values}
+	code := int32(0)
+	k := int32(0)
+	for l := 1; l <= 16; l++ {
+		count := int32(counts[l-1])
+		if count == 0 {
+			t.maxCode[l] = -1
+		} else {
+			t.valPtr[l] = k
+			t.minCode[l] = code
+			t.maxCode[l] = code + count - 1
+			if t.maxCode[l] >= 1<<uint(l) {
+				return nil, fmt.Errorf("invalid Huffman table: too many codes of length %d", l)
+			}
+			if l <= huffmanLookupBits {
+				for c := int32(0); c < count; c++ {
+					shift := uint(huffmanLookupBits - l)
+					first := (code + c) << shift
+					for i := int32(0); i < 1<<shift; i++ {
+						t.lookup[first+i] = uint16(l)<<8 | uint16(values[k+c])
+					}
+				}
+			}
+		}
+		k += count
+		code = (code + count) << 1
+	}
+	return t, nil
+}
+
+// decode reads one Huffman coded value from the bit reader
+func (t *huffmanTable) decode(br *bitReader) (uint8, error) {
+	if br.len < 16 {
+		br.fill()
+	}
+	peek := uint32(br.val >> 48)
+	if entry := t.lookup[peek>>(16-huffmanLookupBits)]; entry != 0 {
+		br.consume(uint8(entry >> 8))
+		return uint8(entry), nil
+	}
+	for l := huffmanLookupBits + 1; l <= 16; l++ {
+		code := int32(peek >> uint(16-l))
+		if code <= t.maxCode[l] {
+			br.consume(uint8(l))
+			return t.values[t.valPtr[l]+code-t.minCode[l]], nil
+		}
+	}
+	return 0, fmt.Errorf("invalid Huffman code %b", peek)
+}
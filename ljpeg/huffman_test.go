@@ -0,0 +1,144 @@
+package ljpeg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// huffKey and decodeMap are the map based decoding the lookahead tables
+// replaced, reading one bit at a time until a code matches. They are kept as
+// a reference for the tests and benchmarks.
+type huffKey struct {
+	bitCount uint8
+	code     uint16
+}
+
+func newHuffmanMap(counts [16]uint8, values []uint8) map[huffKey]uint8 {
+	huffMap := make(map[huffKey]uint8)
+	code := uint16(0)
+	k := 0
+	for i, count := range counts {
+		for j := uint8(0); j < count; j++ {
+			huffMap[huffKey{uint8(i + 1), code}] = values[k]
+			code++
+			k++
+		}
+		code <<= 1
+	}
+	return huffMap
+}
+
+func decodeMap(br *bitReader, huffMap map[huffKey]uint8) (uint8, error) {
+	code := uint16(0)
+	for length := uint8(1); length <= 16; length++ {
+		bit, err := br.readBits(1)
+		if err != nil {
+			return 0, err
+		}
+		code = code<<1 | bit
+		if value, ok := huffMap[huffKey{length, code}]; ok {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid Huffman code %b", code)
+}
+
+// a table of the shape Canon cameras use, with codes up to 12 bits so that
+// both the lookup and the fallback search are exercised
+var testCounts = [16]uint8{0, 1, 3, 3, 2, 2, 1, 1, 1, 1, 1, 1}
+var testValues = []uint8{6, 4, 5, 7, 8, 3, 9, 2, 10, 1, 0, 11, 12, 13, 14, 15, 16}
+
+// huffmanStream returns n random values of the test table, each drawn with
+// the probability 2^-length of its code, and their encoding.
+func huffmanStream(n int) ([]uint8, []byte) {
+	var codes, lengths []uint32
+	code := uint32(0)
+	for i, count := range testCounts {
+		for j := uint8(0); j < count; j++ {
+			codes = append(codes, code)
+			lengths = append(lengths, uint32(i+1))
+			code++
+		}
+		code <<= 1
+	}
+	var total uint32
+	for _, length := range lengths {
+		total += 1 << (16 - length)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	values := make([]uint8, n)
+	var buf bytes.Buffer
+	bw := &bitWriter{w: bufio.NewWriter(&buf)}
+	for i := range values {
+		r := uint32(rng.Int63n(int64(total)))
+		k := 0
+		for r >= 1<<(16-lengths[k]) {
+			r -= 1 << (16 - lengths[k])
+			k++
+		}
+		values[i] = testValues[k]
+		bw.write(codes[k], uint8(lengths[k]))
+	}
+	bw.flush()
+	bw.w.Flush()
+	return values, buf.Bytes()
+}
+
+func TestHuffmanTableMatchesMap(t *testing.T) {
+	table, err := newHuffmanTable(testCounts, testValues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	huffMap := newHuffmanMap(testCounts, testValues)
+	values, data := huffmanStream(10000)
+	brTable, brMap := newBitReader(data), newBitReader(data)
+	for i, want := range values {
+		got, err := table.decode(brTable)
+		if err != nil || got != want {
+			t.Fatalf("table: value %d = %d, %v, want %d", i, got, err, want)
+		}
+		got, err = decodeMap(brMap, huffMap)
+		if err != nil || got != want {
+			t.Fatalf("map: value %d = %d, %v, want %d", i, got, err, want)
+		}
+	}
+}
+
+const benchmarkValues = 1 << 16
+
+func BenchmarkDecodeHuffmanTable(b *testing.B) {
+	table, err := newHuffmanTable(testCounts, testValues)
+	if err != nil {
+		b.Fatal(err)
+	}
+	_, data := huffmanStream(benchmarkValues)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		br := newBitReader(data)
+		for j := 0; j < benchmarkValues; j++ {
+			if _, err := table.decode(br); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeHuffmanMap(b *testing.B) {
+	huffMap := newHuffmanMap(testCounts, testValues)
+	_, data := huffmanStream(benchmarkValues)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		br := newBitReader(data)
+		for j := 0; j < benchmarkValues; j++ {
+			if _, err := decodeMap(br, huffMap); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
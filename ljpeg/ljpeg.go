@@ -0,0 +1,417 @@
+// Package ljpeg decodes and encodes lossless JPEG (ITU T.81 process 14,
+// SOF3) images.
+package ljpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/lpautet/cr2cv/bufreader"
+	"io"
+	"io/ioutil"
+)
+
+// JPEG markers
+const (
+	markerSOF3 = 0xffc3
+	markerDHT  = 0xffc4
+	markerRST0 = 0xffd0
+	markerRST7 = 0xffd7
+	markerSOI  = 0xffd8
+	markerEOI  = 0xffd9
+	markerSOS  = 0xffda
+	markerDRI  = 0xffdd
+)
+
+// FormatError reports malformed or unsupported lossless JPEG data. Offset is
+// the position in the stream where the problem was detected.
+type FormatError struct {
+	Offset int64
+	Msg    string
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("ljpeg: %s at offset %d", e.Msg, e.Offset)
+}
+
+func formatErrorf(offset int64, format string, args ...interface{}) error {
+	return &FormatError{Offset: offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+func wrapError(offset int64, err error) error {
+	if _, ok := err.(*FormatError); ok {
+		return err
+	}
+	return &FormatError{Offset: offset, Msg: err.Error()}
+}
+
+// Component describes one component of the frame, in scan order.
+type Component struct {
+	ID uint8
+	// H and V are the number of samples of the component in an MCU,
+	// horizontally and vertically
+	H, V int
+	// Table is the Huffman table selected by the scan header
+	Table uint8
+}
+
+// Image is a decoded lossless JPEG frame.
+type Image struct {
+	Precision uint8
+	// Width and Height are the number of samples per line and of lines of
+	// the frame
+	Width, Height   int
+	Components      []Component
+	Predictor       uint8
+	PointTransform  uint8
+	RestartInterval int
+	MCUsPerLine     int
+	MCULines        int
+	// Pix holds the samples in decoding order: for every MCU, the H×V
+	// samples of each component in turn, row by row
+	Pix []uint16
+}
+
+// MCUSize returns the number of samples of an MCU.
+func (img *Image) MCUSize() int {
+	size := 0
+	for _, c := range img.Components {
+		size += c.H * c.V
+	}
+	return size
+}
+
+// Plane returns the samples of component c, row by row, with the plane width
+// and height.
+func (img *Image) Plane(c int) ([]uint16, int, int) {
+	offset := 0
+	for _, comp := range img.Components[:c] {
+		offset += comp.H * comp.V
+	}
+	comp := img.Components[c]
+	mcuSize := img.MCUSize()
+	width, height := img.MCUsPerLine*comp.H, img.MCULines*comp.V
+	plane := make([]uint16, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			mcu := (y/comp.V)*img.MCUsPerLine + x/comp.H
+			plane[y*width+x] = img.Pix[mcu*mcuSize+offset+(y%comp.V)*comp.H+x%comp.H]
+		}
+	}
+	return plane, width, height
+}
+
+// Options tunes the decoder and the encoder.
+type Options struct {
+	// Workers is the number of restart intervals processed concurrently
+	Workers int
+	// CanonSRAW predicts the samples of a first component with several
+	// samples per MCU from the previous one in coding order, as Canon sRAW
+	// and mRAW encoders do, instead of from their neighbours in the component
+	CanonSRAW bool
+}
+
+// Decode reads a lossless JPEG image from r. A nil opts decodes with the
+// default options.
+func Decode(r io.Reader, opts *Options) (*Image, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	d := &decoder{
+		data:   data,
+		reader: bufreader.NewBufferReader(bytes.NewReader(data), binary.BigEndian),
+		opts:   opts,
+	}
+	return d.decode()
+}
+
+type decoder struct {
+	data            []byte
+	reader          *bufreader.BufferReader
+	opts            *Options
+	tables          [4]*huffmanTable
+	restartInterval int
+	img             *Image
+	// sampling factors of the frame components, by ID
+	sampling map[uint8][2]int
+	scanned  bool
+}
+
+func (d *decoder) decode() (*Image, error) {
+	soi, err := d.reader.ReadUint16()
+	if err != nil {
+		return nil, wrapError(d.reader.Offset, err)
+	}
+	if soi != markerSOI {
+		return nil, formatErrorf(0, "incorrect SOI magic: %x, expecting %x", soi, markerSOI)
+	}
+	for {
+		marker, err := d.readMarker()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case marker == markerEOI:
+			if !d.scanned {
+				return nil, formatErrorf(d.reader.Offset, "no scan before EOI")
+			}
+			return d.img, nil
+		case marker == markerDHT:
+			err = d.readDHT()
+		case marker == markerDRI:
+			err = d.readDRI()
+		case marker == markerSOF3:
+			err = d.readSOF3()
+		case marker == markerSOS:
+			err = d.readSOS()
+		case marker >= 0xffc0 && marker <= 0xffcf && marker != 0xffc8 && marker != 0xffcc:
+			err = formatErrorf(d.reader.Offset, "unsupported frame type %x, only lossless (SOF3) is", marker)
+		case marker >= markerRST0 && marker <= markerRST7:
+			err = formatErrorf(d.reader.Offset, "unexpected marker %x", marker)
+		default:
+			// APPn, COM, DNL...
+			err = d.skipSegment()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readMarker reads the next marker, skipping fill bytes.
+func (d *decoder) readMarker() (uint16, error) {
+	b, err := d.reader.ReadByte()
+	if err != nil {
+		return 0, wrapError(d.reader.Offset, err)
+	}
+	if b != 0xff {
+		return 0, formatErrorf(d.reader.Offset-1, "expected marker, found %x", b)
+	}
+	for b == 0xff {
+		if b, err = d.reader.ReadByte(); err != nil {
+			return 0, wrapError(d.reader.Offset, err)
+		}
+	}
+	return 0xff00 | uint16(b), nil
+}
+
+// readLength reads the length of a marker segment, and returns the number of
+// bytes following it.
+func (d *decoder) readLength(segment string) (int, error) {
+	length, err := d.reader.ReadUint16()
+	if err != nil {
+		return 0, wrapError(d.reader.Offset, err)
+	}
+	if length < 2 {
+		return 0, formatErrorf(d.reader.Offset, "%s: invalid length %d", segment, length)
+	}
+	return int(length) - 2, nil
+}
+
+func (d *decoder) skipSegment() error {
+	length, err := d.readLength("segment")
+	if err != nil {
+		return err
+	}
+	if d.reader.Offset+int64(length) > int64(len(d.data)) {
+		return formatErrorf(d.reader.Offset, "segment of %d bytes past the end of data", length)
+	}
+	d.reader.Offset += int64(length)
+	return nil
+}
+
+func (d *decoder) readDHT() error {
+	length, err := d.readLength("DHT")
+	if err != nil {
+		return err
+	}
+	for length > 0 {
+		classAndIndex, err := d.reader.ReadByte()
+		if err != nil {
+			return wrapError(d.reader.Offset, err)
+		}
+		tableClass := classAndIndex >> 4
+		tableIndex := classAndIndex & 0xf
+		if tableClass != 0 {
+			return formatErrorf(d.reader.Offset, "DHT: unexpected table class %d", tableClass)
+		}
+		if int(tableIndex) >= len(d.tables) {
+			return formatErrorf(d.reader.Offset, "DHT: invalid table index %d", tableIndex)
+		}
+		counts := [16]uint8{}
+		if err := d.reader.ReadInto(16, &counts); err != nil {
+			return wrapError(d.reader.Offset, err)
+		}
+		total := 0
+		for _, count := range counts {
+			total += int(count)
+		}
+		length -= 17 + total
+		if length < 0 {
+			return formatErrorf(d.reader.Offset, "DHT: table %d longer than its segment", tableIndex)
+		}
+		values, err := d.reader.ReadBuffer(int64(total))
+		if err != nil {
+			return wrapError(d.reader.Offset, err)
+		}
+		if d.tables[tableIndex], err = newHuffmanTable(counts, values); err != nil {
+			return wrapError(d.reader.Offset, err)
+		}
+	}
+	return nil
+}
+
+func (d *decoder) readDRI() error {
+	length, err := d.readLength("DRI")
+	if err != nil {
+		return err
+	}
+	if length != 2 {
+		return formatErrorf(d.reader.Offset, "DRI: unexpected length %d", length+2)
+	}
+	restartInterval, err := d.reader.ReadUint16()
+	if err != nil {
+		return wrapError(d.reader.Offset, err)
+	}
+	d.restartInterval = int(restartInterval)
+	return nil
+}
+
+func (d *decoder) readSOF3() error {
+	if d.img != nil {
+		return formatErrorf(d.reader.Offset, "SOF3: more than one frame")
+	}
+	length, err := d.readLength("SOF3")
+	if err != nil {
+		return err
+	}
+	header := struct {
+		Precision  uint8
+		Lines      uint16
+		Samples    uint16
+		Components uint8
+	}{}
+	if length < 6 {
+		return formatErrorf(d.reader.Offset, "SOF3: segment too short")
+	}
+	if err := d.reader.ReadInto(6, &header); err != nil {
+		return wrapError(d.reader.Offset, err)
+	}
+	if header.Precision < 2 || header.Precision > 16 {
+		return formatErrorf(d.reader.Offset, "SOF3: unsupported precision: %d bits", header.Precision)
+	}
+	if header.Lines == 0 || header.Samples == 0 {
+		return formatErrorf(d.reader.Offset, "SOF3: unsupported size %dx%d", header.Samples, header.Lines)
+	}
+	if header.Components == 0 || length != 6+3*int(header.Components) {
+		return formatErrorf(d.reader.Offset, "SOF3: invalid length %d for %d components", length+2, header.Components)
+	}
+	d.sampling = make(map[uint8][2]int)
+	for i := 0; i < int(header.Components); i++ {
+		info := [3]uint8{}
+		if err := d.reader.ReadInto(3, &info); err != nil {
+			return wrapError(d.reader.Offset, err)
+		}
+		h, v := int(info[1]>>4), int(info[1]&0xf)
+		if h < 1 || h > 4 || v < 1 || v > 4 {
+			return formatErrorf(d.reader.Offset, "SOF3: invalid sampling %dx%d for component %d", h, v, info[0])
+		}
+		if _, ok := d.sampling[info[0]]; ok {
+			return formatErrorf(d.reader.Offset, "SOF3: duplicate component %d", info[0])
+		}
+		d.sampling[info[0]] = [2]int{h, v}
+	}
+	d.img = &Image{
+		Precision: header.Precision,
+		Width:     int(header.Samples),
+		Height:    int(header.Lines),
+	}
+	return nil
+}
+
+func (d *decoder) readSOS() error {
+	if d.img == nil {
+		return formatErrorf(d.reader.Offset, "SOS: no frame header")
+	}
+	if d.scanned {
+		return formatErrorf(d.reader.Offset, "SOS: multiple scans are not supported")
+	}
+	length, err := d.readLength("SOS")
+	if err != nil {
+		return err
+	}
+	count, err := d.reader.ReadByte()
+	if err != nil {
+		return wrapError(d.reader.Offset, err)
+	}
+	if int(count) != len(d.sampling) || length != 4+2*int(count) {
+		return formatErrorf(d.reader.Offset, "SOS: scan of %d components for a frame of %d", count, len(d.sampling))
+	}
+	img := d.img
+	img.Components = make([]Component, count)
+	for i := range img.Components {
+		info := [2]uint8{}
+		if err := d.reader.ReadInto(2, &info); err != nil {
+			return wrapError(d.reader.Offset, err)
+		}
+		sampling, ok := d.sampling[info[0]]
+		if !ok {
+			return formatErrorf(d.reader.Offset, "SOS: unknown component %d", info[0])
+		}
+		for _, c := range img.Components[:i] {
+			if c.ID == info[0] {
+				return formatErrorf(d.reader.Offset, "SOS: duplicate component %d", info[0])
+			}
+		}
+		table := info[1] >> 4
+		if int(table) >= len(d.tables) || d.tables[table] == nil {
+			return formatErrorf(d.reader.Offset, "SOS: unknown Huffman table %d for component %d", table, info[0])
+		}
+		img.Components[i] = Component{ID: info[0], H: sampling[0], V: sampling[1], Table: table}
+	}
+	footer := [3]uint8{}
+	if err := d.reader.ReadInto(3, &footer); err != nil {
+		return wrapError(d.reader.Offset, err)
+	}
+	img.Predictor = footer[0]
+	img.PointTransform = footer[2] & 0xf
+	if img.Predictor < 1 || img.Predictor > 7 {
+		return formatErrorf(d.reader.Offset, "SOS: unsupported predictor %d", img.Predictor)
+	}
+	if footer[1] != 0 || footer[2]>>4 != 0 {
+		return formatErrorf(d.reader.Offset, "SOS: unexpected spectral selection end %d or approximation high %d", footer[1], footer[2]>>4)
+	}
+	if img.PointTransform >= img.Precision {
+		return formatErrorf(d.reader.Offset, "SOS: point transform %d too large for %d bits", img.PointTransform, img.Precision)
+	}
+
+	// a single component scan is not interleaved: one sample per MCU
+	hMax, vMax := 1, 1
+	if len(img.Components) == 1 {
+		img.Components[0].H, img.Components[0].V = 1, 1
+	}
+	for _, c := range img.Components {
+		if c.H > hMax {
+			hMax = c.H
+		}
+		if c.V > vMax {
+			vMax = c.V
+		}
+	}
+	img.MCUsPerLine = (img.Width + hMax - 1) / hMax
+	img.MCULines = (img.Height + vMax - 1) / vMax
+	img.RestartInterval = d.restartInterval
+
+	scan := newScan(img, d.tables, d.opts)
+	end, err := scan.decode(d.data[d.reader.Offset:], d.opts.Workers)
+	if err != nil {
+		return wrapError(d.reader.Offset, err)
+	}
+	d.reader.Offset += int64(end)
+	d.scanned = true
+	return nil
+}
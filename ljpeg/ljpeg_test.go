@@ -0,0 +1,71 @@
+package ljpeg
+
+import (
+	"bytes"
+	"testing"
+)
+
+// benchmarkStream encodes a 14 bit two component frame shaped like a Canon
+// raw slice.
+func benchmarkStream(b *testing.B, restartInterval int) []byte {
+	img := &Image{
+		Precision:       14,
+		Width:           1024,
+		Height:          1024,
+		Components:      []Component{{ID: 1, H: 1, V: 1}, {ID: 2, H: 1, V: 1}},
+		Predictor:       1,
+		RestartInterval: restartInterval,
+	}
+	img.Pix = make([]uint16, 2*img.Width*img.Height)
+	for i := range img.Pix {
+		x, y := i/2%img.Width, i/2/img.Width
+		img.Pix[i] = uint16(2048 + (x*53+y*31)%9000 + (x*y)%17)
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, nil); err != nil {
+		b.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func benchmarkDecode(b *testing.B, restartInterval int, workers int) {
+	data := benchmarkStream(b, restartInterval)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(bytes.NewReader(data), &Options{Workers: workers}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	benchmarkDecode(b, 0, 1)
+}
+
+func BenchmarkDecodeRestartIntervals(b *testing.B) {
+	benchmarkDecode(b, 1024, 0)
+}
+
+// A scan naming a frame component twice leaves another one without samples.
+func TestDecodeDuplicateScanComponent(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, testFrame(12, 8, 8, twoComponents, flat(0)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	sos := bytes.Index(data, []byte{markerSOS >> 8, markerSOS & 0xff})
+	if sos < 0 {
+		t.Fatal("no SOS marker")
+	}
+	// marker, length and count, then the ID and tables of each component
+	data[sos+7] = data[sos+5]
+	_, err := Decode(bytes.NewReader(data), nil)
+	fe, ok := err.(*FormatError)
+	if !ok {
+		t.Fatalf("got error %v, want a *FormatError", err)
+	}
+	if want := "SOS: duplicate component 1"; fe.Msg != want {
+		t.Errorf("got %q, want %q", fe.Msg, want)
+	}
+}
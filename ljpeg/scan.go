@@ -0,0 +1,237 @@
+package ljpeg
+
+import (
+	"fmt"
+	"github.com/lpautet/cr2cv/internal/imageutil"
+)
+
+// scan holds the layout of the MCUs of an image and predicts its samples, for
+// decoding and encoding.
+type scan struct {
+	img *Image
+	// layout of an MCU: component, position in the component and offset in
+	// the MCU of every sample
+	mcuSize    int
+	components []int
+	kx, ky     []int
+	offsets    []int
+	tables     []*huffmanTable
+	// fast path for scans of components with a single sample per MCU
+	simple bool
+	canon  bool
+	// samples of the first component in an MCU, with the Canon prediction
+	lumaSamples     int
+	restartInterval int
+}
+
+func newScan(img *Image, tables [4]*huffmanTable, opts *Options) *scan {
+	sd := &scan{img: img, mcuSize: img.MCUSize(), simple: true}
+	offset := 0
+	for c, comp := range img.Components {
+		for k := 0; k < comp.H*comp.V; k++ {
+			sd.components = append(sd.components, c)
+			sd.kx = append(sd.kx, k%comp.H)
+			sd.ky = append(sd.ky, k/comp.H)
+			sd.offsets = append(sd.offsets, offset)
+			sd.tables = append(sd.tables, tables[comp.Table])
+		}
+		offset += comp.H * comp.V
+		if comp.H*comp.V != 1 {
+			sd.simple = false
+		}
+	}
+	first := img.Components[0]
+	if opts.CanonSRAW && first.H*first.V > 1 {
+		sd.canon = true
+		sd.lumaSamples = first.H * first.V
+	}
+	mcus := img.MCUsPerLine * img.MCULines
+	sd.restartInterval = img.RestartInterval
+	if sd.restartInterval == 0 {
+		sd.restartInterval = mcus
+	}
+	return sd
+}
+
+func (sd *scan) intervals() int {
+	mcus := sd.img.MCUsPerLine * sd.img.MCULines
+	return (mcus + sd.restartInterval - 1) / sd.restartInterval
+}
+
+// interval returns the first and last (excluded) MCU of a restart interval.
+func (sd *scan) interval(interval int) (int, int) {
+	mcus := sd.img.MCUsPerLine * sd.img.MCULines
+	first := interval * sd.restartInterval
+	last := first + sd.restartInterval
+	if last > mcus {
+		last = mcus
+	}
+	return first, last
+}
+
+// decode splits the scan data at its restart markers and decodes each
+// interval into Image.Pix. Prediction never crosses an interval boundary, so
+// intervals are decoded concurrently. It returns the offset of the marker
+// following the scan.
+func (sd *scan) decode(data []byte, workers int) (int, error) {
+	segments, end := splitRestartIntervals(data)
+	expected := sd.intervals()
+	if len(segments) < expected {
+		return 0, fmt.Errorf("found %d restart intervals, expected %d", len(segments), expected)
+	}
+	segments = segments[:expected]
+
+	sd.img.Pix = make([]uint16, sd.img.MCUsPerLine*sd.img.MCULines*sd.mcuSize)
+	errs := make([]error, len(segments))
+	imageutil.ParallelFor(len(segments), workers, func(i int) {
+		errs[i] = sd.decodeInterval(i, data[segments[i][0]:segments[i][1]])
+	})
+	for _, err := range errs {
+		if err != nil {
+			return 0, err
+		}
+	}
+	if pt := sd.img.PointTransform; pt > 0 {
+		for i, v := range sd.img.Pix {
+			sd.img.Pix[i] = v << pt
+		}
+	}
+	return end, nil
+}
+
+func (sd *scan) decodeInterval(interval int, data []byte) error {
+	img := sd.img
+	bits := newBitReader(data)
+	bitsPerSample := img.Precision - img.PointTransform
+	first, last := sd.interval(interval)
+	for mcu := first; mcu < last; mcu++ {
+		base := mcu * sd.mcuSize
+		for s := 0; s < sd.mcuSize; s++ {
+			diffValue, err := bits.readDiff(sd.tables[s])
+			if err != nil {
+				return fmt.Errorf("%v at MCU %d, sample %d", err, mcu, s)
+			}
+			previousValue := sd.prediction(img.Pix, mcu, s, first)
+			// reconstruction is modulo 2^16 whatever the precision
+			newVal := (previousValue + diffValue) & 0xffff
+			if newVal >= 1<<bitsPerSample {
+				return fmt.Errorf("new value does not fit in %d bits %d+%d=%d at MCU %d, sample %d", bitsPerSample, previousValue, diffValue, newVal, mcu, s)
+			}
+			img.Pix[base+s] = uint16(newVal)
+		}
+	}
+	return nil
+}
+
+// prediction returns the predicted value of sample s of an MCU, from the
+// samples of pix already coded in the restart interval starting at MCU first.
+func (sd *scan) prediction(pix []uint16, mcu int, s int, first int) int {
+	img := sd.img
+	width := img.MCUsPerLine
+	stride := width * sd.mcuSize
+	pos := mcu*sd.mcuSize + s
+	defaultValue := 1 << (img.Precision - img.PointTransform - 1)
+	i := mcu % width
+	switch {
+	case sd.simple:
+		left := i > 0 && mcu > first
+		above := mcu-width >= first
+		switch {
+		case !left && !above:
+			return defaultValue
+		case !left:
+			return int(pix[pos-stride])
+		case !above || mcu-width-1 < first:
+			return int(pix[pos-sd.mcuSize])
+		}
+		return predict(img.Predictor, int(pix[pos-sd.mcuSize]), int(pix[pos-stride]), int(pix[pos-stride-sd.mcuSize]))
+	case sd.canon && s < sd.lumaSamples:
+		switch {
+		case s > 0:
+			return int(pix[pos-1])
+		case i > 0 && mcu > first:
+			return int(pix[pos-sd.mcuSize+sd.lumaSamples-1])
+		case mcu-width >= first:
+			return int(pix[pos-stride])
+		}
+		return defaultValue
+	}
+	return sd.predictSample(pix, s, mcu, first, defaultValue)
+}
+
+// predictSample returns the prediction of sample s of an MCU from its
+// neighbours in the plane of its component, when they belong to the current
+// restart interval.
+func (sd *scan) predictSample(pix []uint16, s int, mcu int, first int, defaultValue int) int {
+	img := sd.img
+	comp := img.Components[sd.components[s]]
+	x := mcu%img.MCUsPerLine*comp.H + sd.kx[s]
+	y := mcu/img.MCUsPerLine*comp.V + sd.ky[s]
+	// index of the sample at (x, y) of the plane, or -1 outside of the
+	// interval
+	at := func(x, y int) int {
+		if x < 0 || y < 0 {
+			return -1
+		}
+		m := y/comp.V*img.MCUsPerLine + x/comp.H
+		if m < first {
+			return -1
+		}
+		return m*sd.mcuSize + sd.offsets[s] + y%comp.V*comp.H + x%comp.H
+	}
+	left, above, diagonal := at(x-1, y), at(x, y-1), at(x-1, y-1)
+	switch {
+	case left < 0 && above < 0:
+		return defaultValue
+	case above < 0 || (left >= 0 && diagonal < 0):
+		return int(pix[left])
+	case left < 0:
+		return int(pix[above])
+	}
+	return predict(img.Predictor, int(pix[left]), int(pix[above]), int(pix[diagonal]))
+}
+
+// predict applies one of the predictors of ITU T.81 table H.1 to the samples
+// left (Ra), above (Rb) and above left (Rc) of the current one.
+func predict(predictor uint8, ra, rb, rc int) int {
+	switch predictor {
+	case 2:
+		return rb
+	case 3:
+		return rc
+	case 4:
+		return ra + rb - rc
+	case 5:
+		return ra + (rb-rc)>>1
+	case 6:
+		return rb + (ra-rc)>>1
+	case 7:
+		return (ra + rb) >> 1
+	}
+	return ra
+}
+
+// splitRestartIntervals returns the [start, end) offsets of the entropy coded
+// segments separated by RSTn markers, and the offset of the marker ending the
+// scan.
+func splitRestartIntervals(data []byte) ([][2]int, int) {
+	var segments [][2]int
+	start := 0
+	for pos := 0; pos+1 < len(data); pos++ {
+		if data[pos] != 0xff || data[pos+1] == 0x00 {
+			continue
+		}
+		if data[pos+1] >= 0xd0 && data[pos+1] <= 0xd7 {
+			segments = append(segments, [2]int{start, pos})
+			start = pos + 2
+			pos++
+			continue
+		}
+		if data[pos+1] == 0xff {
+			// fill byte
+			continue
+		}
+		return append(segments, [2]int{start, pos}), pos
+	}
+	return append(segments, [2]int{start, len(data)}), len(data)
+}